@@ -4,9 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"html/template"
+	"log"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
+	texttemplate "text/template"
+	"time"
 
 	"github.com/moonrhythm/parapet"
 )
@@ -16,17 +20,83 @@ type App struct {
 	srv           *parapet.Server
 	handler       http.Handler
 	routes        Routes
+	errorPages    ErrorPages
 	globals       sync.Map
 	onceServeHTTP sync.Once
 	serveHandler  http.Handler
+	onceValidate  sync.Once
+	validateErr   error
 
-	template        map[string]*tmpl
-	component       map[string]*tmpl
-	cachedComponent sync.Map
-	parent          *template.Template
+	template    map[string]*tmpl
+	component   map[string]*tmpl
+	layouts     map[string]*layout
+	renderCache renderCache
+	parent      *template.Template
+
+	contextFuncs     map[string]ContextFunc
+	contextFallbacks map[string]any // funcs used while render without context
+
+	i18n     i18n
+	timeZone *time.Location // default time zone for format funcs
+	assets   *assets
+	statics  []*staticHandler
+
+	sanitizers map[string]*sanitizer
+	csp        *csp
+	cspReport  func(r *http.Request, report *CSPReport)
+
+	text       map[string]*texttemplate.Template
+	textParent *texttemplate.Template
 
 	ETag bool
 
+	// Stream renders View and Component directly into the response
+	// instead of buffering the whole body, see Context.Stream.
+	//
+	// Output is flushed in chunks, so a template error after the first chunk
+	// can not change the response, it is returned as *StreamError.
+	// ETag only applies when the whole body fits in the first chunk.
+	Stream bool
+
+	// Dev enables development mode,
+	// which recovers panics and renders handler errors as a debug page
+	// with the error chain, template source, request and stack trace.
+	//
+	// It is disabled by default and must never be enabled in production,
+	// the debug page exposes source code and request data.
+	Dev bool
+
+	// TemplateReload re-parses views and components
+	// when their template files are modified, for development.
+	// Files are checked on every render.
+	TemplateReload bool
+
+	// LiveReload enables browser live reload in development mode.
+	// HTML documents rendered by View, Component and Render get a script
+	// which reloads the page when templates or directories added by
+	// LiveReloadWatch change, using server-sent events from LiveReloadPath.
+	// It has no effect unless Dev is enabled.
+	LiveReload bool
+
+	liveReload liveReload
+
+	// Strict validates templates before serve, see Validate.
+	// When templates are invalid, ListenAndServe and Serve return the error,
+	// ServeHTTP logs the error once then responds every request with 500.
+	Strict bool
+
+	// ErrorHandler handles errors returned from Handler,
+	// except *StreamError which is only logged.
+	// DefaultErrorHandler is used when nil.
+	ErrorHandler ErrorHandler
+
+	// ViewData returns values which are merged into the data
+	// of View, Component and Render with Context.SetViewData's values,
+	// when the data is nil or map[string]any.
+	// Handler's data takes precedence over the default values.
+	// It is called once per Context, the result is reused by later renders.
+	ViewData ViewDataFunc
+
 	// CookieSigner signs and verifies cookies for AddSignedCookie and
 	// SignedCookieValue. It is nil by default; set it to enable signed cookies.
 	CookieSigner CookieSigner
@@ -63,16 +133,41 @@ func (app *App) Clone() *App {
 			TLSConfig:          app.srv.TLSConfig.Clone(),
 			BaseContext:        app.srv.BaseContext,
 		},
-		handler:      app.handler,
-		routes:       cloneRoutes(app.routes),
-		globals:      cloneMap(&app.globals),
-		template:     cloneTmpl(app.template),
-		parent:       template.Must(app.parent.Clone()),
-		ETag:         app.ETag,
-		CookieSigner: app.CookieSigner,
+		handler:        app.handler,
+		routes:         cloneRoutes(app.routes),
+		errorPages:     cloneErrorPages(app.errorPages),
+		globals:        cloneMap(&app.globals),
+		template:       cloneTmpl(app.template),
+		layouts:        cloneLayouts(app.layouts),
+		contextFuncs:   cloneContextFuncs(app.contextFuncs),
+		i18n:           cloneI18n(app.i18n),
+		timeZone:       app.timeZone,
+		assets:         app.assets,
+		sanitizers:     cloneSanitizers(app.sanitizers),
+		csp:            app.csp,
+		cspReport:      app.cspReport,
+		text:           cloneTextTmpl(app.text),
+		textParent:     texttemplate.Must(app.textParent.Clone()),
+		parent:         template.Must(app.parent.Clone()),
+		ETag:           app.ETag,
+		Stream:         app.Stream,
+		Dev:            app.Dev,
+		TemplateReload: app.TemplateReload,
+		LiveReload:     app.LiveReload,
+		Strict:         app.Strict,
+		ErrorHandler:   app.ErrorHandler,
+		ViewData:       app.ViewData,
+		CookieSigner:   app.CookieSigner,
 	}
 	x.srv.Handler = x
 	x.setupParent()
+	x.cloneContextFallbacks(app.contextFallbacks)
+	for _, s := range app.statics {
+		x.statics = append(x.statics, s.clone(x))
+	}
+	x.liveReload.dirs = append([]string(nil), app.liveReload.dirs...)
+	x.liveReload.dirsSig = app.liveReload.dirsSig
+	x.renderCache.configure(app.renderCache.config())
 
 	return x
 }
@@ -95,16 +190,50 @@ func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		app.serveHandler = app.ServeHandler(app.serveHandler)
+
+		if err := app.validateStrict(); err != nil {
+			app.logf("hime: invalid templates; %v", err)
+		}
 	})
+	if app.validateErr != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
 
 	app.serveHandler.ServeHTTP(w, r)
 }
 
 func (app *App) ServeHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.liveReloadEnabled() && r.URL.Path == LiveReloadPath {
+			app.serveLiveReload(w, r)
+			return
+		}
+		if app.assets != nil && strings.HasPrefix(r.URL.Path, app.assets.prefix) {
+			app.assets.serve(w, r, app.TemplateReload)
+			return
+		}
+		if app.csp != nil && app.csp.reportPath != "" && r.URL.Path == app.csp.reportPath {
+			app.serveCSPReport(w, r)
+			return
+		}
+
+		if app.i18n.prefix {
+			r = app.stripLocalePrefix(r)
+		}
+
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, ctxKeyApp{}, app)
 		r = r.WithContext(ctx)
+		if app.Dev {
+			defer app.devRecover(w, r)
+		}
+		for _, s := range app.statics {
+			if s.match(r.URL.Path) {
+				s.serve(w, r, h)
+				return
+			}
+		}
 		h.ServeHTTP(w, r)
 	})
 }
@@ -129,14 +258,38 @@ func (app *App) Shutdown() error {
 
 // ListenAndServe starts web server
 func (app *App) ListenAndServe() error {
+	if err := app.validateStrict(); err != nil {
+		return err
+	}
 	return app.srv.ListenAndServe()
 }
 
 // Serve serves listener
 func (app *App) Serve(l net.Listener) error {
+	if err := app.validateStrict(); err != nil {
+		return err
+	}
 	return app.srv.Serve(l)
 }
 
+// validateStrict validates templates once in strict mode
+func (app *App) validateStrict() error {
+	app.onceValidate.Do(func() {
+		if app.Strict {
+			app.validateErr = app.Validate()
+		}
+	})
+	return app.validateErr
+}
+
+func (app *App) logf(format string, a ...any) {
+	if app.srv.ErrorLog != nil {
+		app.srv.ErrorLog.Printf(format, a...)
+		return
+	}
+	log.Printf(format, a...)
+}
+
 func (app *App) ensureTLSConfig() {
 	if app.srv.TLSConfig == nil {
 		app.srv.TLSConfig = &tls.Config{}
@@ -167,13 +320,35 @@ func (app *App) setupParent() {
 	if app.parent == nil {
 		app.parent = template.New("")
 	}
+	if app.textParent == nil {
+		app.textParent = texttemplate.New("")
+	}
+	if app.text == nil {
+		app.text = make(map[string]*texttemplate.Template)
+	}
 	if app.template == nil {
 		app.template = make(map[string]*tmpl)
 	}
 	if app.component == nil {
 		app.component = make(map[string]*tmpl)
 	}
-	app.parent.Funcs(template.FuncMap{
+	if app.layouts == nil {
+		app.layouts = make(map[string]*layout)
+	}
+	app.contextFallbacks = map[string]any{
+		"component": app.renderComponent,
+		"route":     app.Route,
+		"nonce":     func() string { return "" },
+		"t": func(key string, args ...any) string {
+			return app.T(app.i18n.defaultLocale, key, args...)
+		},
+		"locale": func() string { return app.i18n.defaultLocale },
+	}
+	if app.contextFuncs == nil {
+		app.contextFuncs = make(map[string]ContextFunc)
+		app.ContextFuncs(defaultContextFuncs())
+	}
+	funcs := template.FuncMap{
 		"param":        tfParam,
 		"templateName": func() string { return "" },
 		"component":    app.renderComponent,
@@ -181,7 +356,29 @@ func (app *App) setupParent() {
 		"global":       app.Global,
 		"dict":         tfDict,
 		"json":         tfJSON,
-	})
+		"asset":        app.Asset,
+		"integrity":    app.AssetIntegrity,
+		"markdown":     tfMarkdown,
+		"sanitize":     app.sanitizeFunc,
+		"frontMatter":  func() map[string]any { return nil },
+		"toc":          func() []MarkdownHeading { return nil },
+	}
+	app.parent.Funcs(funcs)
+	app.textParent.Funcs(funcs)
+}
+
+// cloneContextFallbacks copies fallbacks registered after setupParent (e.g. format funcs),
+// format funcs are rebound to app
+func (app *App) cloneContextFallbacks(fallbacks map[string]any) {
+	for name, f := range fallbacks {
+		if _, ok := app.contextFallbacks[name]; ok {
+			continue
+		}
+		if fn, ok := formatFuncs[name]; ok {
+			f = fn(&formatter{app: app})
+		}
+		app.contextFallbacks[name] = f
+	}
 }
 
 func getApp(ctx context.Context) *App {
@@ -0,0 +1,284 @@
+package hime
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tdewolff/minify/v2"
+)
+
+// assetHashLen is the length of content hash in asset's file name
+const assetHashLen = 8
+
+// AssetsConfig is assets config
+//
+// Example:
+//
+//	assets:
+//	  dir: static
+//	  prefix: /static/
+//	  minify: true
+type AssetsConfig struct {
+	// Dir is the directory of assets
+	Dir string `yaml:"dir" json:"dir"`
+
+	// Prefix is the url path of assets, default is "/static/"
+	Prefix string `yaml:"prefix" json:"prefix"`
+
+	// Minify minifies css and js files
+	Minify bool `yaml:"minify" json:"minify"`
+}
+
+// asset is a fingerprinted file
+type asset struct {
+	name      string // file name, e.g. css/app.css
+	hashed    string // file name with hash, e.g. css/app.3f9a1c2b.css
+	content   []byte
+	etag      string
+	integrity string
+	modTime   time.Time
+}
+
+type assets struct {
+	fs     fs.FS
+	prefix string
+	m      *minify.M
+
+	mu     sync.RWMutex
+	files  map[string]*asset // name => asset
+	hashed map[string]*asset // hashed name => asset
+}
+
+// Assets loads assets from the directory, see AssetsFS
+func (app *App) Assets(cfg AssetsConfig) {
+	app.AssetsFS(os.DirFS(cfg.Dir), cfg)
+}
+
+// AssetsFS loads assets from fsys, and serves them at the prefix.
+//
+// Template func {{asset "app.css"}} returns the url with content hash,
+// e.g. /static/app.3f9a1c2b.css, which is served with immutable Cache-Control,
+// and {{integrity "app.css"}} returns the asset's subresource integrity.
+// Unhashed names are served with no-cache.
+func (app *App) AssetsFS(fsys fs.FS, cfg AssetsConfig) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "/static/"
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	a := &assets{
+		fs:     fsys,
+		prefix: prefix,
+		files:  make(map[string]*asset),
+		hashed: make(map[string]*asset),
+	}
+	if cfg.Minify {
+		a.m = newMinifier(defaultMinifyConfig())
+	}
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		x, err := a.load(p)
+		if err != nil {
+			return err
+		}
+		a.add(x)
+		return nil
+	})
+	if err != nil {
+		panicf("load assets; %v", err)
+	}
+
+	app.assets = a
+}
+
+// AssetsHandler returns the handler which serves assets,
+// the handler is added to ServeHandler when assets are loaded
+func (app *App) AssetsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.assets == nil {
+			http.NotFound(w, r)
+			return
+		}
+		app.assets.serve(w, r, app.TemplateReload)
+	})
+}
+
+// Asset returns the url of the asset with content hash
+func (app *App) Asset(name string) (string, error) {
+	x, err := app.lookupAsset(name)
+	if err != nil {
+		return "", err
+	}
+	return app.assets.prefix + x.hashed, nil
+}
+
+// AssetIntegrity returns the subresource integrity of the asset
+func (app *App) AssetIntegrity(name string) (string, error) {
+	x, err := app.lookupAsset(name)
+	if err != nil {
+		return "", err
+	}
+	return x.integrity, nil
+}
+
+func (app *App) lookupAsset(name string) (*asset, error) {
+	if app.assets == nil {
+		return nil, newErrAssetNotFound(name)
+	}
+	x := app.assets.lookup(strings.TrimPrefix(name, "/"), app.TemplateReload)
+	if x == nil {
+		return nil, newErrAssetNotFound(name)
+	}
+	return x, nil
+}
+
+func (a *assets) load(name string) (*asset, error) {
+	content, err := fs.ReadFile(a.fs, name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fs.Stat(a.fs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.m != nil {
+		var mediaType string
+		switch path.Ext(name) {
+		case ".css":
+			mediaType = "text/css"
+		case ".js", ".mjs":
+			mediaType = "application/javascript"
+		}
+		if mediaType != "" {
+			var b bytes.Buffer
+			if err := a.m.Minify(mediaType, &b, bytes.NewReader(content)); err == nil {
+				content = b.Bytes()
+			}
+		}
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])[:assetHashLen]
+	sri := sha512.Sum384(content)
+
+	ext := path.Ext(name)
+	return &asset{
+		name:      name,
+		hashed:    strings.TrimSuffix(name, ext) + "." + hash + ext,
+		content:   content,
+		etag:      `"` + hash + `"`,
+		integrity: "sha384-" + base64.StdEncoding.EncodeToString(sri[:]),
+		modTime:   fi.ModTime(),
+	}, nil
+}
+
+func (a *assets) add(x *asset) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if old, ok := a.files[x.name]; ok {
+		delete(a.hashed, old.hashed)
+	}
+	a.files[x.name] = x
+	a.hashed[x.hashed] = x
+}
+
+// lookup returns the asset, reloads the asset when it was modified if reload is true
+func (a *assets) lookup(name string, reload bool) *asset {
+	a.mu.RLock()
+	x := a.files[name]
+	a.mu.RUnlock()
+
+	if x == nil && !reload {
+		return nil
+	}
+	if reload {
+		fi, err := fs.Stat(a.fs, name)
+		if err != nil || fi.IsDir() {
+			return x
+		}
+		if x == nil || fi.ModTime().After(x.modTime) {
+			nx, err := a.load(name)
+			if err != nil {
+				return x
+			}
+			a.add(nx)
+			x = nx
+		}
+	}
+	return x
+}
+
+// unhash returns the asset's name from the hashed name, e.g. app.3f9a1c2b.css => app.css
+func unhashAssetName(name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexByte(base, '.')
+	if i < 0 || len(base)-i-1 != assetHashLen {
+		return ""
+	}
+	if _, err := hex.DecodeString(base[i+1:]); err != nil {
+		return ""
+	}
+	return base[:i] + ext
+}
+
+func (a *assets) serve(w http.ResponseWriter, r *http.Request, reload bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, a.prefix)
+	if !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	a.mu.RLock()
+	x := a.hashed[name]
+	a.mu.RUnlock()
+
+	if x != nil {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		// unhashed name, or the hash of other version
+		if n := unhashAssetName(name); n != "" && a.lookup(n, reload) != nil {
+			name = n
+		}
+		x = a.lookup(name, reload)
+		if x == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if ct := mime.TypeByExtension(path.Ext(x.name)); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("ETag", x.etag)
+	http.ServeContent(w, r, x.name, x.modTime, bytes.NewReader(x.content))
+}
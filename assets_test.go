@@ -0,0 +1,143 @@
+package hime
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssets(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"app.css":          {Data: []byte("body {\n  color: red;\n}\n")},
+		"js/app.js":        {Data: []byte("var a = 1;\n")},
+		"img/logo.svg":     {Data: []byte("<svg></svg>")},
+		"lib.0123abcd.js":  {Data: []byte("lib")},
+		"dir/nested/a.txt": {Data: []byte("a")},
+	}
+
+	newApp := func(minify bool) *App {
+		app := New()
+		app.AssetsFS(fsys, AssetsConfig{Minify: minify})
+		return app
+	}
+
+	t.Run("asset", func(t *testing.T) {
+		app := newApp(false)
+		p, err := app.Asset("app.css")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^/static/app\.[0-9a-f]{8}\.css$`, p)
+
+		p, err = app.Asset("/js/app.js")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^/static/js/app\.[0-9a-f]{8}\.js$`, p)
+
+		sri, err := app.AssetIntegrity("app.css")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(sri, "sha384-"))
+
+		_, err = app.Asset("missing.css")
+		var notFound *ErrAssetNotFound
+		assert.True(t, errors.As(err, &notFound))
+	})
+
+	t.Run("template", func(t *testing.T) {
+		app := newApp(false)
+		app.Template().Parse("page", `<link href="{{asset "app.css"}}" integrity="{{integrity "app.css"}}">`)
+		p, _ := app.Asset("app.css")
+		sri, _ := app.AssetIntegrity("app.css")
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "page", nil))
+		assert.Equal(t, `<link href="`+p+`" integrity="`+strings.ReplaceAll(sri, "+", "&#43;")+`">`, b.String())
+	})
+
+	t.Run("minify", func(t *testing.T) {
+		app := newApp(true)
+		p, _ := app.Asset("app.css")
+		w := invokeHandler(app, http.MethodGet, p, nil)
+		assert.Equal(t, "body{color:red}", w.Body.String())
+
+		plain, _ := newApp(false).Asset("app.css")
+		assert.NotEqual(t, plain, p)
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		app := newApp(false)
+		p, _ := app.Asset("app.css")
+
+		w := invokeHandler(app, http.MethodGet, p, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "body {\n  color: red;\n}\n", w.Body.String())
+
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+		r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		w = invokeHandler(app, http.MethodGet, "/static/app.css", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+		w = invokeHandler(app, http.MethodGet, "/static/app.00000000.css", nil)
+		assert.Equal(t, http.StatusOK, w.Code, "stale hash falls back to current version")
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+		w = invokeHandler(app, http.MethodGet, "/static/lib.0123abcd.js", nil)
+		assert.Equal(t, "lib", w.Body.String(), "file name which looks hashed")
+
+		w = invokeHandler(app, http.MethodGet, "/static/missing.css", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = invokeHandler(app, http.MethodPost, p, nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		app := newApp(false)
+		app.Template().Parse("page", `{{asset "app.css"}}{{asset "missing.css"}}{{integrity}}`)
+		err := app.Validate()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "hime: asset 'missing.css' not found")
+			assert.Contains(t, err.Error(), "integrity wants 1 argument, got 0")
+		}
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "app.css")
+		writeTemplateFile(t, filename, "a{}", time.Now().Add(-time.Hour))
+
+		app := New()
+		app.TemplateReload = true
+		app.Assets(AssetsConfig{Dir: dir, Prefix: "/assets"})
+		p1, err := app.Asset("app.css")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(p1, "/assets/app."))
+
+		writeTemplateFile(t, filename, "b{}", time.Now())
+		p2, _ := app.Asset("app.css")
+		assert.NotEqual(t, p1, p2)
+
+		w := invokeHandler(app, http.MethodGet, p2, nil)
+		assert.Equal(t, "b{}", w.Body.String())
+		w = invokeHandler(app, http.MethodGet, p1, nil)
+		assert.Equal(t, "b{}", w.Body.String())
+
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "new.css"), []byte("c{}"), 0644))
+		_, err = app.Asset("new.css")
+		assert.NoError(t, err)
+	})
+}
@@ -10,38 +10,120 @@ import (
 type AppConfig struct {
 	Globals   Globals          `yaml:"globals" json:"globals"`
 	Routes    Routes           `yaml:"routes" json:"routes"`
+	Errors    ErrorPages       `yaml:"errors" json:"errors"`
 	Templates []TemplateConfig `yaml:"templates" json:"templates"`
+	I18n      I18nConfig       `yaml:"i18n" json:"i18n"`
+
+	// Format enables format template funcs, see App.FormatFuncs
+	Format *FormatConfig `yaml:"format" json:"format"`
+
+	// Assets loads fingerprinted assets, see App.AssetsFS
+	Assets *AssetsConfig `yaml:"assets" json:"assets"`
+
+	// Static serves files before the app's handler, see App.StaticFS
+	Static []StaticConfig `yaml:"static" json:"static"`
+
+	// Sanitize registers sanitize policies, see App.SanitizePolicy
+	Sanitize map[string]SanitizePolicy `yaml:"sanitize" json:"sanitize"`
+
+	// CSP sets Content-Security-Policy header, see App.CSP
+	CSP *CSPConfig `yaml:"csp" json:"csp"`
 }
 
 // Config merges config into app's config
 //
 // Example:
 //
-// globals:
+//	globals:
+//	  data1: test
+//
+//	routes:
+//	  index: /
+//	  about: /about
+//
+//	errors:
+//	  404: error/404
+//	  5xx: error/5xx
+//
+//	i18n:
+//	  default: en
+//	  catalogs:
+//	    th: [locales/th.yaml]
 //
-//	data1: test
+//	format:
+//	  timeZone: Asia/Bangkok
 //
-// routes:
+//	assets:
+//	  dir: static
+//	  minify: true
 //
-//	index: /
-//	about: /about
+//	static:
+//	  - dir: public
+//	    cacheControl: public, max-age=3600
+//	    fallback: app
 //
-// templates:
-//   - dir: view
-//     root: layout
-//     delims: ["{{", "}}"]
-//     minify: true
-//     preload:
-//   - comp/comp1.tmpl
-//   - comp/comp2.tmpl
-//     list:
-//     main.tmpl:
-//   - main.tmpl
-//   - _layout.tmpl
-//     about.tmpl: [about.tmpl, _layout.tmpl]
+//	sanitize:
+//	  comment:
+//	    elements:
+//	      p: []
+//	      a: [href]
+//	    linkRel: nofollow noopener
+//
+//	csp:
+//	  directives:
+//	    default-src: ["'self'"]
+//	    script-src: ["'self'", "'nonce'"]
+//	  reportPath: /_csp
+//
+//	templates:
+//	  - dir: view
+//	    root: layout
+//	    delims: ["{{", "}}"]
+//	    minify: true
+//	    preload:
+//	      - comp/comp1.tmpl
+//	      - comp/comp2.tmpl
+//	    components:
+//	      - comp/*.tmpl
+//	      - card: card.tmpl
+//	    list:
+//	      main.tmpl:
+//	        - main.tmpl
+//	        - _layout.tmpl
+//	      about.tmpl: [about.tmpl, _layout.tmpl]
+//	    text:
+//	      welcome: [mail/welcome.txt]
+//	  - dir: view
+//	    root: layout
+//	    layout: [_layout.tmpl]
+//	    pages: pages
+//	  - dir: docs
+//	    root: layout
+//	    content: body
+//	    markdown:
+//	      anchors: true
+//	    list:
+//	      intro: [_layout.tmpl, intro.md]
 func (app *App) Config(config AppConfig) {
 	app.Globals(config.Globals)
 	app.Routes(config.Routes)
+	app.ErrorPages(config.Errors)
+	app.I18n(config.I18n)
+	if config.Format != nil {
+		app.FormatFuncs(*config.Format)
+	}
+	if config.Assets != nil {
+		app.Assets(*config.Assets)
+	}
+	for _, cfg := range config.Static {
+		app.mountStatic(cfg)
+	}
+	for name, policy := range config.Sanitize {
+		app.SanitizePolicy(name, policy)
+	}
+	if config.CSP != nil {
+		app.CSP(*config.CSP)
+	}
 
 	for _, cfg := range config.Templates {
 		app.Template().Config(cfg)
@@ -1,6 +1,8 @@
 package hime
 
 import (
+	"encoding/json"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -96,3 +98,64 @@ func TestConfigParseConfigFileEmptyName(t *testing.T) {
 
 	assert.Panics(t, func() { New().ParseConfigFile("") })
 }
+
+func TestConfigComponents(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yaml", func(t *testing.T) {
+		app := New()
+		app.ParseConfigFile("testdata/components/config.yaml")
+
+		assert.Len(t, app.component, 4)
+		assert.Contains(t, app.component, "card")
+		assert.Contains(t, app.component, "badge")
+		assert.Contains(t, app.component, "alert")
+		assert.Contains(t, app.component, "button")
+
+		assert.Equal(t, `<main><div class="card">c</div><p role="alert">a</p><button>ok</button></main>`+"\n", renderView(app, "index"))
+	})
+
+	t.Run("map", func(t *testing.T) {
+		app := New()
+		app.ParseConfig([]byte(`
+templates:
+- dir: testdata/components
+  components:
+    card: comp/card.tmpl
+    b: comp/badge.tmpl
+`))
+
+		assert.Len(t, app.component, 2)
+		assert.Contains(t, app.component, "card")
+		assert.Contains(t, app.component, "b")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var cfg TemplateConfig
+		err := json.Unmarshal([]byte(`{"components": ["comp/*.tmpl", {"button": "button.tmpl"}]}`), &cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, TemplateComponents{
+			Files: map[string]string{"button": "button.tmpl"},
+			Globs: []string{"comp/*.tmpl"},
+		}, cfg.Components)
+	})
+
+	t.Run("fs", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.FS(os.DirFS("testdata/components"))
+		tp.ParseComponentGlob("widgets")
+
+		assert.Len(t, app.component, 1)
+		assert.Contains(t, app.component, "alert")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Panics(t, func() {
+			New().ParseConfig([]byte(`
+templates:
+- components: comp/card.tmpl
+`))
+		})
+	})
+}
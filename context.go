@@ -28,10 +28,12 @@ func NewContext(w http.ResponseWriter, r *http.Request) *Context {
 // NewAppContext creates new hime's context with given app
 func NewAppContext(app *App, w http.ResponseWriter, r *http.Request) *Context {
 	return &Context{
-		Request: r,
-		app:     app,
-		w:       w,
-		etag:    app.ETag,
+		Request:  r,
+		app:      app,
+		w:        w,
+		etag:     app.ETag,
+		stream:   app.Stream,
+		cspNonce: new(string),
 	}
 }
 
@@ -42,9 +44,18 @@ type Context struct {
 	app *App
 	w   http.ResponseWriter
 
-	code  int
-	etag  bool
-	flash map[string][]string
+	code     int
+	etag     bool
+	stream   bool
+	layout   *layout
+	noLayout bool
+	viewData map[string]any
+	hookData map[string]any // result of App.ViewData, computed once
+	locale   string
+	timeZone *time.Location
+	funcs    template.FuncMap // context funcs bound to the context
+	flash    map[string][]string
+	cspNonce *string // shared with copies of the context
 }
 
 // Deadline implements context.Context
@@ -71,6 +82,8 @@ func (ctx *Context) Value(key any) any {
 func (ctx *Context) WithRequest(r *http.Request) *Context {
 	nctx := *ctx
 	nctx.Request = r
+	nctx.funcs = nil
+	nctx.hookData = nil
 	return &nctx
 }
 
@@ -78,6 +91,7 @@ func (ctx *Context) WithRequest(r *http.Request) *Context {
 func (ctx *Context) WithResponseWriter(w http.ResponseWriter) *Context {
 	nctx := *ctx
 	nctx.w = w
+	nctx.funcs = nil
 	return &nctx
 }
 
@@ -159,7 +173,7 @@ func (ctx *Context) SafeRedirect(url string, params ...any) error {
 
 // RedirectTo redirects to route name
 func (ctx *Context) RedirectTo(name string, params ...any) error {
-	p := buildPath(ctx.app.Route(name), params...)
+	p := buildPath(ctx.Route(name), params...)
 	return ctx.Redirect(p)
 }
 
@@ -197,14 +211,23 @@ func (ctx *Context) SafeRedirectBack(fallback string) error {
 	return ctx.SafeRedirect(u)
 }
 
-// Error calls http.Error
+// Error renders the error page for the status code,
+// or calls http.Error when no error page is configured
 func (ctx *Context) Error(error string) error {
-	http.Error(ctx.w, error, ctx.statusCodeError())
+	code := ctx.statusCodeError()
+	if ctx.renderErrorPage(code, error) {
+		return nil
+	}
+	http.Error(ctx.w, error, code)
 	return nil
 }
 
-// NotFound calls http.NotFound
+// NotFound renders the 404 error page,
+// or calls http.NotFound when no error page is configured
 func (ctx *Context) NotFound() error {
+	if ctx.renderErrorPage(http.StatusNotFound, http.StatusText(http.StatusNotFound)) {
+		return nil
+	}
 	http.NotFound(ctx.w, ctx.Request)
 	return nil
 }
@@ -231,46 +254,69 @@ func (ctx *Context) setETag(b []byte) bool {
 
 // View renders view
 func (ctx *Context) View(name string, data any) error {
-	t, ok := ctx.app.template[name]
+	data = ctx.withViewData(data)
+
+	t, ok := ctx.app.lookupTemplate(name)
 	if !ok {
 		panic(newErrTemplateNotFound(name))
 	}
 
+	t, err := ctx.viewTmpl(t)
+	if err != nil {
+		return err
+	}
+
+	if ctx.streaming() {
+		return ctx.streamTmpl(t, data)
+	}
+
 	buf := getBytes()
 	defer putBytes(buf)
 
-	err := t.Execute(buf, data)
+	err = t.ExecuteContext(ctx, buf, data)
 	if err != nil {
 		return err
 	}
 
-	if ctx.setETag(buf.Bytes()) {
-		return nil
-	}
-
-	ctx.setContentType("text/html; charset=utf-8")
-	return ctx.CopyFrom(buf)
+	return ctx.writeHTML(buf)
 }
 
 // Component renders component
 func (ctx *Context) Component(name string, data any) error {
-	t, ok := ctx.app.component[name]
+	data = ctx.withViewData(data)
+
+	t, ok := ctx.app.lookupComponent(name)
 	if !ok {
 		panic(newErrComponentNotFound(name))
 	}
 
+	if ctx.streaming() {
+		return ctx.streamTmpl(t, data)
+	}
+
 	buf := getBytes()
 	defer putBytes(buf)
 
-	err := t.Execute(buf, data)
+	err := t.ExecuteContext(ctx, buf, data)
 	if err != nil {
 		return err
 	}
 
+	return ctx.writeHTML(buf)
+}
+
+// writeHTML writes rendered html from buf into response writer
+func (ctx *Context) writeHTML(buf *bytes.Buffer) error {
+	// htmx swaps fragments into the page which already has the script
+	if ctx.app.liveReloadEnabled() && !ctx.IsHTMX() {
+		injectLiveReload(buf, ctx.cspScriptNonce())
+	}
+
 	if ctx.setETag(buf.Bytes()) {
 		return nil
 	}
 
+	ctx.setCSPHeader()
 	ctx.setContentType("text/html; charset=utf-8")
 	return ctx.CopyFrom(buf)
 }
@@ -280,7 +326,9 @@ func (ctx *Context) Component(name string, data any) error {
 // out-of-band swaps) from several components. It panics if the component is not
 // found, matching Component.
 func (ctx *Context) RenderComponentToString(name string, data any) (string, error) {
-	t, ok := ctx.app.component[name]
+	data = ctx.withViewData(data)
+
+	t, ok := ctx.app.lookupComponent(name)
 	if !ok {
 		panic(newErrComponentNotFound(name))
 	}
@@ -288,19 +336,22 @@ func (ctx *Context) RenderComponentToString(name string, data any) (string, erro
 	buf := getBytes()
 	defer putBytes(buf)
 
-	if err := t.Execute(buf, data); err != nil {
+	if err := t.ExecuteContext(ctx, buf, data); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-// Render renders html template
+// Render renders html template,
+// compiled templates are cached, see App.RenderCache
 func (ctx *Context) Render(tmpl string, data any) error {
+	data = ctx.withViewData(data)
+
 	hash := sha1.Sum([]byte(tmpl))
 	key := hex.EncodeToString(hash[:]) + "|" + strconv.Itoa(len(tmpl))
 
-	if t, ok := ctx.app.cachedComponent.Load(key); ok {
-		return ctx.executeTemplate(t.(*template.Template), data)
+	if t, ok := ctx.app.renderCache.get(key); ok {
+		return ctx.executeTemplate(t, data)
 	}
 
 	t, err := ctx.app.parent.Clone()
@@ -313,31 +364,28 @@ func (ctx *Context) Render(tmpl string, data any) error {
 		return err
 	}
 
-	ctx.app.cachedComponent.Store(key, t)
+	c := &compiledTmpl{t: t, pool: newTemplatePool(ctx.app, t, nil)}
+	ctx.app.renderCache.set(key, c)
 
-	return ctx.executeTemplate(t, data)
+	return ctx.executeTemplate(c, data)
 }
 
-func (ctx *Context) executeTemplate(t *template.Template, data any) error {
-	if !ctx.etag {
+func (ctx *Context) executeTemplate(c *compiledTmpl, data any) error {
+	if !ctx.etag && !ctx.app.liveReloadEnabled() {
+		ctx.setCSPHeader()
 		ctx.setContentType("text/html; charset=utf-8")
-		return filterRenderError(t.Execute(ctx.w, data))
+		return filterRenderError(c.run(ctx, ctx.w, "", data))
 	}
 
 	buf := getBytes()
 	defer putBytes(buf)
 
-	err := t.Execute(buf, data)
+	err := c.run(ctx, buf, "", data)
 	if err != nil {
 		return err
 	}
 
-	if ctx.setETag(buf.Bytes()) {
-		return nil
-	}
-
-	ctx.setContentType("text/html; charset=utf-8")
-	return ctx.CopyFrom(buf)
+	return ctx.writeHTML(buf)
 }
 
 func (ctx *Context) setContentType(value string) {
@@ -0,0 +1,267 @@
+package hime
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sync"
+	"text/template/parse"
+)
+
+// ContextFunc returns a template func bound to the request's context
+type ContextFunc func(ctx *Context) any
+
+// ContextFuncs registers request-scoped template funcs, must call before load templates.
+//
+// Each func is bound to the context when render View, Component, Render,
+// and components rendered by them.
+// Templates which use context funcs are executed from a pool of clones,
+// so the funcs are bound without cloning templates for each request.
+// Calling a context func while render without context returns an error.
+//
+// A context func is replaced by app's template func with the same name
+// registered after it (see TemplateFuncs), and by Template.Funcs for templates loaded by it.
+func (app *App) ContextFuncs(funcs map[string]ContextFunc) {
+	for name, f := range funcs {
+		app.contextFuncs[name] = f
+		unbound := template.FuncMap{
+			name: app.unboundContextFunc(name),
+		}
+		app.parent.Funcs(unbound)
+		app.textParent.Funcs(unbound)
+	}
+}
+
+// ContextFunc registers a request-scoped template func, see ContextFuncs
+func (app *App) ContextFunc(name string, f ContextFunc) {
+	app.ContextFuncs(map[string]ContextFunc{name: f})
+}
+
+// unboundContextFunc returns the func used while render without context
+func (app *App) unboundContextFunc(name string) any {
+	if f, ok := app.contextFallbacks[name]; ok {
+		return f
+	}
+	return func(...any) (any, error) {
+		return nil, fmt.Errorf("hime: template func '%s' requires context", name)
+	}
+}
+
+// defaultContextFuncs returns built-in request-scoped template funcs
+func defaultContextFuncs() map[string]ContextFunc {
+	return map[string]ContextFunc{
+		"component": func(ctx *Context) any {
+			return ctx.renderComponent
+		},
+		"viewData": func(ctx *Context) any {
+			return ctx.viewDataValue
+		},
+		"nonce": func(ctx *Context) any {
+			return ctx.CSPNonce
+		},
+		"isRoute": func(ctx *Context) any {
+			return ctx.IsRoute
+		},
+		"t": func(ctx *Context) any {
+			return ctx.T
+		},
+		"locale": func(ctx *Context) any {
+			return ctx.Locale
+		},
+	}
+}
+
+// templateFuncs returns context funcs bound to ctx
+func (ctx *Context) templateFuncs() template.FuncMap {
+	if ctx.funcs != nil {
+		return ctx.funcs
+	}
+
+	m := make(template.FuncMap, len(ctx.app.contextFuncs))
+	for name, f := range ctx.app.contextFuncs {
+		m[name] = f(ctx)
+	}
+	ctx.funcs = m
+	return m
+}
+
+// renderComponent renders component with context funcs bound to ctx
+func (ctx *Context) renderComponent(name string, args ...any) template.HTML {
+	return ctx.app.renderComponentContext(ctx, name, args...)
+}
+
+// contextFuncNames returns names of funcs which are bound per render
+func (app *App) contextFuncNames() map[string]bool {
+	rs := make(map[string]bool, len(app.contextFuncs))
+	for name := range app.contextFuncs {
+		rs[name] = true
+	}
+	return rs
+}
+
+// templatePool is the pool of clones of a template which uses context funcs,
+// the master template is never executed so it can be cloned
+type templatePool struct {
+	master *template.Template
+	reset  template.FuncMap
+	mu     sync.Mutex
+	free   []*template.Template
+}
+
+// newTemplatePool returns the pool for t,
+// or nil when t does not use context funcs,
+// shadowed are names of t's funcs which replace context funcs
+func newTemplatePool(app *App, t *template.Template, shadowed map[string]bool) *templatePool {
+	names := app.contextFuncNames()
+	for name := range shadowed {
+		delete(names, name)
+	}
+	if !usesFuncs(t, names) {
+		return nil
+	}
+
+	reset := make(template.FuncMap, len(names))
+	for name := range names {
+		reset[name] = app.unboundContextFunc(name)
+	}
+	return &templatePool{master: t, reset: reset}
+}
+
+// lookup returns the pool for the named template in master's set
+func (p *templatePool) lookup(name string) *templatePool {
+	x := p.master.Lookup(name)
+	if x == nil {
+		return nil
+	}
+	return &templatePool{master: x, reset: p.reset}
+}
+
+func (p *templatePool) get() *template.Template {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		t := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.mu.Unlock()
+		return t
+	}
+	p.mu.Unlock()
+
+	return template.Must(p.master.Clone())
+}
+
+// funcs returns ctx's context funcs which are bound to the pool's templates
+func (p *templatePool) funcs(ctx *Context) template.FuncMap {
+	m := ctx.templateFuncs()
+	if len(m) == len(p.reset) {
+		return m
+	}
+
+	rs := make(template.FuncMap, len(p.reset))
+	for name := range p.reset {
+		if f, ok := m[name]; ok {
+			rs[name] = f
+		}
+	}
+	return rs
+}
+
+func (p *templatePool) put(t *template.Template) {
+	// unbind funcs, so the pool does not keep the context
+	t.Funcs(p.reset)
+
+	p.mu.Lock()
+	p.free = append(p.free, t)
+	p.mu.Unlock()
+}
+
+// run executes the template, or the named template from its set when name is not empty,
+// with context funcs bound to ctx, ctx can be nil
+func (c *compiledTmpl) run(ctx *Context, w io.Writer, name string, data any) error {
+	if c.pool == nil {
+		return executeNamed(c.t, w, name, data)
+	}
+
+	x := c.pool.get()
+	defer c.pool.put(x)
+
+	if ctx != nil {
+		x.Funcs(c.pool.funcs(ctx))
+	}
+	return executeNamed(x, w, name, data)
+}
+
+func executeNamed(t *template.Template, w io.Writer, name string, data any) error {
+	if name == "" {
+		return t.Execute(w, data)
+	}
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// usesFuncs reports whether any template in t's set calls any of names
+func usesFuncs(t *template.Template, names map[string]bool) bool {
+	for _, x := range t.Templates() {
+		if x.Tree != nil && nodeUsesFuncs(x.Tree.Root, names) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeUsesFuncs(node parse.Node, names map[string]bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, x := range n.Nodes {
+			if nodeUsesFuncs(x, names) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUsesFuncs(n.Pipe, names)
+	case *parse.IfNode:
+		return branchUsesFuncs(&n.BranchNode, names)
+	case *parse.RangeNode:
+		return branchUsesFuncs(&n.BranchNode, names)
+	case *parse.WithNode:
+		return branchUsesFuncs(&n.BranchNode, names)
+	case *parse.TemplateNode:
+		return n.Pipe != nil && nodeUsesFuncs(n.Pipe, names)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if nodeUsesFuncs(cmd, names) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if nodeUsesFuncs(arg, names) {
+				return true
+			}
+		}
+	case *parse.IdentifierNode:
+		return names[n.Ident]
+	}
+	return false
+}
+
+func branchUsesFuncs(n *parse.BranchNode, names map[string]bool) bool {
+	return nodeUsesFuncs(n.Pipe, names) ||
+		nodeUsesFuncs(n.List, names) ||
+		(n.ElseList != nil && nodeUsesFuncs(n.ElseList, names))
+}
+
+func cloneContextFuncs(xs map[string]ContextFunc) map[string]ContextFunc {
+	if xs == nil {
+		return nil
+	}
+	rs := make(map[string]ContextFunc, len(xs))
+	for k, v := range xs {
+		rs[k] = v
+	}
+	return rs
+}
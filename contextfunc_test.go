@@ -0,0 +1,150 @@
+package hime
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextFunc(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		app.Routes(Routes{"about": "/about"})
+		app.ContextFunc("path", func(ctx *Context) any {
+			return func() string { return ctx.URL.Path }
+		})
+		tp := app.Template()
+		tp.ParseComponent("c", `[{{path}}]`)
+		tp.Parse("page", `{{define "frag"}}{{path}}{{end}}{{path}}|{{component "c"}}|{{isRoute "about"}}`)
+		tp.Parse("static", `static`)
+		return app
+	}
+	render := func(app *App, target string, f func(ctx *Context) error) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		assert.NoError(t, f(NewAppContext(app, w, r)))
+		return w.Body.String()
+	}
+
+	t.Run("view", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "/about|[/about]|true", render(app, "/about", func(ctx *Context) error {
+			return ctx.View("page", nil)
+		}))
+		assert.Equal(t, "/home|[/home]|false", render(app, "/home", func(ctx *Context) error {
+			return ctx.View("page", nil)
+		}))
+	})
+
+	t.Run("component fragment and render", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "[/a]", render(app, "/a", func(ctx *Context) error {
+			return ctx.Component("c", nil)
+		}))
+		assert.Equal(t, "/b", render(app, "/b", func(ctx *Context) error {
+			return ctx.ViewFragment("page", "frag", nil)
+		}))
+		assert.Equal(t, "/c|[/c]", render(app, "/c", func(ctx *Context) error {
+			return ctx.Render(`{{path}}|{{component "c"}}`, nil)
+		}))
+		assert.Equal(t, "/d|[/d]", render(app, "/d", func(ctx *Context) error {
+			return ctx.Render(`{{path}}|{{component "c"}}`, nil)
+		}), "cached")
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "/s|[/s]|false", render(app, "/s", func(ctx *Context) error {
+			return ctx.Stream(true).View("page", nil)
+		}))
+	})
+
+	t.Run("viewData", func(t *testing.T) {
+		app := New()
+		app.ViewData = func(ctx *Context) map[string]any {
+			return map[string]any{"User": "guest"}
+		}
+		app.Template().Parse("page", `{{viewData "User"}}`)
+		assert.Equal(t, "alice", render(app, "/", func(ctx *Context) error {
+			ctx.SetViewData("User", "alice")
+			return ctx.View("page", "not a map")
+		}))
+	})
+
+	t.Run("replaced by template funcs", func(t *testing.T) {
+		app := newApp()
+		app.TemplateFunc("viewData", func(key string) string { return "app " + key })
+		tp := app.Template()
+		tp.Func("isRoute", func(name string) string { return "tp " + name })
+		tp.Parse("replaced", `{{viewData "x"}}|{{isRoute "about"}}|{{path}}|{{component "r"}}`)
+		app.Template().ParseComponent("r", `{{isRoute "about"}}`)
+
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, "app x|tp about|/about|true", render(app, "/about", func(ctx *Context) error {
+				return ctx.View("replaced", nil)
+			}))
+		}
+	})
+
+	t.Run("without context", func(t *testing.T) {
+		app := newApp()
+		var b strings.Builder
+		err := app.template["page"].Execute(&b, nil)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "hime: template func 'path' requires context")
+		}
+	})
+
+	t.Run("templates without context funcs are not pooled", func(t *testing.T) {
+		app := newApp()
+		assert.Nil(t, app.template["static"].c.Load().pool)
+		assert.NotNil(t, app.template["page"].c.Load().pool)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		app := newApp()
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p := fmt.Sprintf("/p%d", i)
+				assert.Equal(t, p+"|["+p+"]|false", render(app, p, func(ctx *Context) error {
+					return ctx.View("page", nil)
+				}))
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		app := newApp().Clone()
+		app.Template().Parse("p", `{{path}}`)
+		assert.Equal(t, "/x", render(app, "/x", func(ctx *Context) error {
+			return ctx.View("p", nil)
+		}))
+	})
+}
+
+func BenchmarkContextFunc(b *testing.B) {
+	app := New()
+	app.ContextFunc("path", func(ctx *Context) any {
+		return func() string { return ctx.URL.Path }
+	})
+	app.Template().Parse("page", `<p>{{path}}</p>`)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		NewAppContext(app, w, r).View("page", nil)
+	}
+}
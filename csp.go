@@ -0,0 +1,285 @@
+package hime
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CSPNonceSource is the source replaced with the request's nonce, see Context.CSPNonce
+const CSPNonceSource = "'nonce'"
+
+// cspReportMaxSize is the maximum size of report request's body
+const cspReportMaxSize = 64 << 10
+
+// cspReportGroup is the endpoint's name in Reporting-Endpoints header
+const cspReportGroup = "csp-endpoint"
+
+// CSPConfig is Content-Security-Policy config
+//
+// Example:
+//
+//	csp:
+//	  directives:
+//	    default-src: ["'self'"]
+//	    script-src: ["'self'", "'nonce'"]
+//	    style-src: ["'self'", "'nonce'"]
+//	  reportOnly: true
+//	  reportPath: /_csp
+type CSPConfig struct {
+	// Directives are the policy's directives to their sources,
+	// CSPNonceSource is replaced with the request's nonce
+	Directives map[string][]string `yaml:"directives" json:"directives"`
+
+	// ReportOnly sends Content-Security-Policy-Report-Only header instead
+	ReportOnly bool `yaml:"reportOnly" json:"reportOnly"`
+
+	// ReportPath is the path of report endpoint, served by ServeHandler,
+	// added to the policy as report-uri and report-to
+	ReportPath string `yaml:"reportPath" json:"reportPath"`
+}
+
+// Directive adds sources to the directive
+func (cfg *CSPConfig) Directive(name string, sources ...string) *CSPConfig {
+	if cfg.Directives == nil {
+		cfg.Directives = make(map[string][]string)
+	}
+	cfg.Directives[name] = append(cfg.Directives[name], sources...)
+	return cfg
+}
+
+// Policy returns the policy with the nonce,
+// directives are sorted by name with default-src first
+func (cfg CSPConfig) Policy(nonce string) string {
+	names := make([]string, 0, len(cfg.Directives))
+	for name := range cfg.Directives {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "default-src") != (names[j] == "default-src") {
+			return names[i] == "default-src"
+		}
+		return names[i] < names[j]
+	})
+
+	var xs []string
+	for _, name := range names {
+		d := []string{name}
+		for _, s := range cfg.Directives[name] {
+			if s == CSPNonceSource {
+				if nonce == "" {
+					continue
+				}
+				s = "'nonce-" + nonce + "'"
+			}
+			d = append(d, s)
+		}
+		xs = append(xs, strings.Join(d, " "))
+	}
+	if cfg.ReportPath != "" {
+		xs = append(xs, "report-uri "+cfg.ReportPath, "report-to "+cspReportGroup)
+	}
+	return strings.Join(xs, "; ")
+}
+
+// csp is a compiled CSPConfig
+type csp struct {
+	header     string
+	parts      []string // policy split at nonces
+	reportPath string
+}
+
+// CSP sets Content-Security-Policy header to html rendered by View, Component and Render.
+//
+// Template func {{nonce}} returns the request's nonce, e.g. <script nonce="{{nonce}}">,
+// and reports sent to ReportPath are passed to the handler set by OnCSPReport.
+func (app *App) CSP(cfg CSPConfig) {
+	for name, sources := range cfg.Directives {
+		for _, s := range append([]string{name}, sources...) {
+			if s == "" || strings.ContainsAny(s, ";,\r\n") {
+				panicf("invalid csp directive '%s'", name)
+			}
+		}
+	}
+
+	c := &csp{
+		header:     "Content-Security-Policy",
+		parts:      strings.Split(cfg.Policy("\x00"), "\x00"),
+		reportPath: cfg.ReportPath,
+	}
+	if cfg.ReportOnly {
+		c.header = "Content-Security-Policy-Report-Only"
+	}
+	app.csp = c
+}
+
+// OnCSPReport sets the handler of reports sent to CSPConfig's ReportPath,
+// default logs reports to the server's ErrorLog
+func (app *App) OnCSPReport(f func(r *http.Request, report *CSPReport)) {
+	app.cspReport = f
+}
+
+// CSPNonce returns the request's nonce for inline scripts and styles
+func (ctx *Context) CSPNonce() string {
+	if ctx.cspNonce == nil {
+		ctx.cspNonce = new(string)
+	}
+	if *ctx.cspNonce == "" {
+		*ctx.cspNonce = rand.Text()
+	}
+	return *ctx.cspNonce
+}
+
+// cspScriptNonce returns the nonce for scripts injected into html,
+// or empty string when the policy does not use nonce
+func (ctx *Context) cspScriptNonce() string {
+	if ctx.app.csp == nil || len(ctx.app.csp.parts) < 2 {
+		return ""
+	}
+	return ctx.CSPNonce()
+}
+
+// setCSPHeader sets the app's policy unless the handler already set it
+func (ctx *Context) setCSPHeader() {
+	c := ctx.app.csp
+	if c == nil {
+		return
+	}
+	h := ctx.w.Header()
+	if h.Get(c.header) != "" {
+		return
+	}
+	h.Set(c.header, strings.Join(c.parts, ctx.cspScriptNonce()))
+	if c.reportPath != "" {
+		h.Set("Reporting-Endpoints", cspReportGroup+`="`+c.reportPath+`"`)
+	}
+}
+
+// CSPReport is a Content-Security-Policy violation report
+type CSPReport struct {
+	DocumentURL        string `json:"documentURL"`
+	Referrer           string `json:"referrer"`
+	BlockedURL         string `json:"blockedURL"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	OriginalPolicy     string `json:"originalPolicy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"sourceFile"`
+	Sample             string `json:"sample"`
+	StatusCode         int    `json:"statusCode"`
+	LineNumber         int    `json:"lineNumber"`
+	ColumnNumber       int    `json:"columnNumber"`
+	UserAgent          string `json:"userAgent"`
+}
+
+// legacyCSPReport is the report sent to report-uri
+type legacyCSPReport struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	ScriptSample       string `json:"script-sample"`
+	StatusCode         int    `json:"status-code"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+}
+
+// ParseCSPReport parses violation reports from the request's body,
+// sent to report-uri (application/csp-report)
+// or by Reporting API (application/reports+json).
+// Reports of other types are ignored.
+func ParseCSPReport(r *http.Request) ([]*CSPReport, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, errors.New("hime: empty csp report")
+	}
+
+	if body[0] == '[' {
+		var reports []struct {
+			Type      string    `json:"type"`
+			URL       string    `json:"url"`
+			UserAgent string    `json:"user_agent"`
+			Body      CSPReport `json:"body"`
+		}
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, err
+		}
+		var rs []*CSPReport
+		for _, x := range reports {
+			if x.Type != "csp-violation" {
+				continue
+			}
+			report := x.Body
+			if report.DocumentURL == "" {
+				report.DocumentURL = x.URL
+			}
+			report.UserAgent = x.UserAgent
+			rs = append(rs, &report)
+		}
+		return rs, nil
+	}
+
+	var legacy struct {
+		Report *legacyCSPReport `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, err
+	}
+	x := legacy.Report
+	if x == nil {
+		return nil, errors.New("hime: invalid csp report")
+	}
+	directive := x.EffectiveDirective
+	if directive == "" {
+		directive = x.ViolatedDirective
+	}
+	return []*CSPReport{{
+		DocumentURL:        x.DocumentURI,
+		Referrer:           x.Referrer,
+		BlockedURL:         x.BlockedURI,
+		EffectiveDirective: directive,
+		OriginalPolicy:     x.OriginalPolicy,
+		Disposition:        x.Disposition,
+		SourceFile:         x.SourceFile,
+		Sample:             x.ScriptSample,
+		StatusCode:         x.StatusCode,
+		LineNumber:         x.LineNumber,
+		ColumnNumber:       x.ColumnNumber,
+		UserAgent:          r.UserAgent(),
+	}}, nil
+}
+
+func (app *App) serveCSPReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, cspReportMaxSize)
+	reports, err := ParseCSPReport(r)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	for _, report := range reports {
+		if app.cspReport != nil {
+			app.cspReport(r, report)
+			continue
+		}
+		app.logf("hime: csp violation; %s blocked '%s' on %s", report.EffectiveDirective, report.BlockedURL, report.DocumentURL)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
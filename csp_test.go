@@ -0,0 +1,200 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("policy", func(t *testing.T) {
+		var cfg CSPConfig
+		cfg.Directive("script-src", "'self'", CSPNonceSource).
+			Directive("default-src", "'self'").
+			Directive("img-src", "*")
+		assert.Equal(t, "default-src 'self'; img-src *; script-src 'self' 'nonce-abc'", cfg.Policy("abc"))
+		assert.Equal(t, "default-src 'self'; img-src *; script-src 'self'", cfg.Policy(""))
+
+		cfg.ReportPath = "/_csp"
+		assert.Equal(t, "default-src 'self'; img-src *; script-src 'self'; report-uri /_csp; report-to csp-endpoint", cfg.Policy(""))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Panics(t, func() {
+			New().CSP(CSPConfig{Directives: map[string][]string{"script-src": {"'self'; img-src *"}}})
+		})
+	})
+
+	t.Run("view", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("index", `<script nonce="{{nonce}}"></script>`)
+		app.CSP(CSPConfig{Directives: map[string][]string{
+			"default-src": {"'self'"},
+			"script-src":  {CSPNonceSource},
+		}})
+
+		nonces := map[string]bool{}
+		for _, etag := range []bool{false, true} {
+			app.ETag = etag
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx := NewAppContext(app, w, r)
+			assert.NoError(t, ctx.View("index", nil))
+
+			nonce := ctx.CSPNonce()
+			assert.NotEmpty(t, nonce)
+			assert.Equal(t, "default-src 'self'; script-src 'nonce-"+nonce+"'", w.Header().Get("Content-Security-Policy"))
+			assert.Equal(t, `<script nonce="`+nonce+`"></script>`, w.Body.String())
+			nonces[nonce] = true
+		}
+		assert.Len(t, nonces, 2)
+	})
+
+	t.Run("render", func(t *testing.T) {
+		app := New()
+		app.ParseConfig([]byte(`
+csp:
+  directives:
+    default-src: ["'self'"]
+  reportOnly: true
+  reportPath: /_csp
+`))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, NewAppContext(app, w, r).Render(`<p>ok</p>`, nil))
+		assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "default-src 'self'; report-uri /_csp; report-to csp-endpoint", w.Header().Get("Content-Security-Policy-Report-Only"))
+		assert.Equal(t, `csp-endpoint="/_csp"`, w.Header().Get("Reporting-Endpoints"))
+	})
+
+	t.Run("handler header", func(t *testing.T) {
+		app := New()
+		app.CSP(CSPConfig{Directives: map[string][]string{"default-src": {"'self'"}}})
+
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Security-Policy", "default-src 'none'")
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, NewAppContext(app, w, r).Render(`ok`, nil))
+		assert.Equal(t, "default-src 'none'", w.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("live reload", func(t *testing.T) {
+		app := New()
+		app.Dev = true
+		app.LiveReload = true
+		app.Template().Parse("index", `<body></body>`)
+		app.CSP(CSPConfig{Directives: map[string][]string{"script-src": {CSPNonceSource}}})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx := NewAppContext(app, w, r)
+		assert.NoError(t, ctx.View("index", nil))
+		assert.Contains(t, w.Body.String(), `<script nonce="`+ctx.CSPNonce()+`">`)
+	})
+
+	t.Run("app's nonce func", func(t *testing.T) {
+		app := New()
+		app.TemplateFunc("nonce", func() string { return "app" })
+		app.Template().Parse("index", `<p data-nonce="{{nonce}}">{{viewData "A"}}</p>`)
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx := NewAppContext(app, w, r)
+			ctx.SetViewData("A", "a")
+			assert.NoError(t, ctx.View("index", nil))
+			assert.Equal(t, `<p data-nonce="app">a</p>`, w.Body.String())
+		}
+	})
+
+	t.Run("without context", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("index", `<p data-nonce="{{nonce}}"></p>`)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "index", nil))
+		assert.Equal(t, `<p data-nonce=""></p>`, b.String())
+	})
+}
+
+func TestCSPReport(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.CSP(CSPConfig{
+		Directives: map[string][]string{"default-src": {"'self'"}},
+		ReportPath: "/_csp",
+	})
+	var reports []*CSPReport
+	app.OnCSPReport(func(r *http.Request, report *CSPReport) {
+		reports = append(reports, report)
+	})
+	h := app.ServeHandler(http.NotFoundHandler())
+
+	post := func(contentType, body string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/_csp", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("User-Agent", "test")
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("report-uri", func(t *testing.T) {
+		reports = nil
+		assert.Equal(t, http.StatusNoContent, post("application/csp-report", `{"csp-report":{
+			"document-uri":"https://example.com/",
+			"blocked-uri":"https://evil.com/x.js",
+			"violated-directive":"script-src-elem",
+			"original-policy":"default-src 'self'",
+			"disposition":"enforce",
+			"line-number":3
+		}}`))
+		assert.Equal(t, []*CSPReport{{
+			DocumentURL:        "https://example.com/",
+			BlockedURL:         "https://evil.com/x.js",
+			EffectiveDirective: "script-src-elem",
+			OriginalPolicy:     "default-src 'self'",
+			Disposition:        "enforce",
+			LineNumber:         3,
+			UserAgent:          "test",
+		}}, reports)
+	})
+
+	t.Run("reporting api", func(t *testing.T) {
+		reports = nil
+		assert.Equal(t, http.StatusNoContent, post("application/reports+json", `[
+			{"type":"csp-violation","url":"https://example.com/a","user_agent":"browser","body":{
+				"blockedURL":"inline","effectiveDirective":"style-src-elem","disposition":"report","sample":"p{}"
+			}},
+			{"type":"deprecation","url":"https://example.com/a","body":{}}
+		]`))
+		assert.Equal(t, []*CSPReport{{
+			DocumentURL:        "https://example.com/a",
+			BlockedURL:         "inline",
+			EffectiveDirective: "style-src-elem",
+			Disposition:        "report",
+			Sample:             "p{}",
+			UserAgent:          "browser",
+		}}, reports)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post("application/csp-report", `{"x":1}`))
+		assert.Equal(t, http.StatusBadRequest, post("application/csp-report", ``))
+		assert.Equal(t, http.StatusBadRequest, post("application/csp-report", strings.Repeat(" ", cspReportMaxSize)+"[]"))
+	})
+
+	t.Run("method", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_csp", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+	})
+}
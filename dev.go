@@ -0,0 +1,222 @@
+package hime
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// devSourceContext is the number of lines shown around the failing template line
+const devSourceContext = 5
+
+// devRecover recovers a panic from the handler and writes the development error page,
+// must be called directly by defer
+func (app *App) devRecover(w http.ResponseWriter, r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	err, ok := v.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", v)
+	}
+	writeDevError(w, r, err, debug.Stack())
+}
+
+// isDevError reports whether err should be shown in the development error page,
+// errors which intentionally respond with 4xx status are not,
+// neither are errors after a streaming response was started
+func isDevError(err error) bool {
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Status >= 400 && httpErr.Status < 500 {
+		return false
+	}
+	var problem *Problem
+	if errors.As(err, &problem) && problem.Status >= 400 && problem.Status < 500 {
+		return false
+	}
+	return true
+}
+
+type devPage struct {
+	Error     string
+	Chain     []devChainEntry
+	Templates []devTemplate
+	Method    string
+	URL       string
+	Header    http.Header
+	Form      url.Values
+	Stack     string
+}
+
+type devChainEntry struct {
+	Type    string
+	Message string
+}
+
+type devTemplate struct {
+	Kind   string
+	Name   string
+	File   string
+	Line   int
+	Column int
+	Lines  []devSourceLine
+	Error  string
+}
+
+type devSourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+func writeDevError(w http.ResponseWriter, r *http.Request, err error, stack []byte) {
+	if r.Form == nil {
+		r.ParseMultipartForm(defaultMaxMemory)
+	}
+
+	p := devPage{
+		Error:  err.Error(),
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Header: r.Header,
+		Form:   r.Form,
+		Stack:  string(stack),
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		p.Chain = append(p.Chain, devChainEntry{
+			Type:    fmt.Sprintf("%T", e),
+			Message: e.Error(),
+		})
+
+		if te, ok := e.(*TemplateError); ok {
+			p.Templates = append(p.Templates, newDevTemplate(te))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	devPageTemplate.Execute(w, &p)
+}
+
+// templateErrorLocation matches the location prefix of text/template errors,
+// i.e. "template: name:line:col: ..." or "template: name:line: ..."
+var templateErrorLocation = regexp.MustCompile(`^template: (.+?):(\d+):(?:(\d+):)? `)
+
+func newDevTemplate(err *TemplateError) devTemplate {
+	t := devTemplate{
+		Kind:  "view",
+		Name:  err.Name,
+		Error: err.Err.Error(),
+	}
+	if err.Component {
+		t.Kind = "component"
+	}
+
+	m := templateErrorLocation.FindStringSubmatch(t.Error)
+	if m == nil {
+		return t
+	}
+	t.File = m[1]
+	t.Line, _ = strconv.Atoi(m[2])
+	t.Column, _ = strconv.Atoi(m[3])
+
+	src, ok := err.sources[t.File]
+	if !ok {
+		return t
+	}
+	if src.path != "" {
+		t.File = src.path
+	}
+	text, rerr := src.read()
+	if rerr != nil {
+		return t
+	}
+
+	lines := strings.Split(text, "\n")
+	from := max(t.Line-devSourceContext, 1)
+	to := min(t.Line+devSourceContext, len(lines))
+	for i := from; i <= to; i++ {
+		t.Lines = append(t.Lines, devSourceLine{
+			Number:  i,
+			Text:    lines[i-1],
+			Current: i == t.Line,
+		})
+	}
+	return t
+}
+
+var devPageTemplate = template.Must(template.New("").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Error: {{.Error}}</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; color: #222; }
+h1 { color: #b00020; font-size: 1.4rem; word-break: break-word; }
+h2 { font-size: 1.1rem; margin-top: 2rem; border-bottom: 1px solid #ddd; }
+pre { background: #f6f6f6; padding: .75rem; overflow-x: auto; }
+table { border-collapse: collapse; }
+td, th { text-align: left; vertical-align: top; padding: .2rem .75rem .2rem 0; font-family: monospace; }
+.source td { padding: 0 .5rem; white-space: pre; }
+.source .current { background: #ffe0e0; font-weight: bold; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Error}}</h1>
+<p class="muted">{{.Method}} {{.URL}} &middot; hime development mode</p>
+
+{{range .Templates}}
+<h2>{{.Kind}} '{{.Name}}'{{if .File}} &middot; {{.File}}{{if .Line}}:{{.Line}}{{if .Column}}:{{.Column}}{{end}}{{end}}{{end}}</h2>
+<pre>{{.Error}}</pre>
+{{if .Lines}}
+<table class="source">
+{{range .Lines}}<tr{{if .Current}} class="current"{{end}}><td class="muted">{{.Number}}</td><td>{{.Text}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{end}}
+
+<h2>Error chain</h2>
+<table>
+{{range .Chain}}<tr><th>{{.Type}}</th><td>{{.Message}}</td></tr>
+{{end}}
+</table>
+
+<h2>Request headers</h2>
+<table>
+{{range $k, $v := .Header}}<tr><th>{{$k}}</th><td>{{range $v}}{{.}}<br>{{end}}</td></tr>
+{{end}}
+</table>
+
+<h2>Form values</h2>
+{{if .Form}}
+<table>
+{{range $k, $v := .Form}}<tr><th>{{$k}}</th><td>{{range $v}}{{.}}<br>{{end}}</td></tr>
+{{end}}
+</table>
+{{else}}
+<p class="muted">none</p>
+{{end}}
+
+<h2>Stack trace</h2>
+<pre>{{.Stack}}</pre>
+</body>
+</html>
+`))
@@ -0,0 +1,145 @@
+package hime
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDev(t *testing.T) {
+	t.Parallel()
+
+	t.Run("template error", func(t *testing.T) {
+		app := New()
+		app.Dev = true
+		app.TemplateFunc("panic", func() string { panic("boom") })
+		tp := app.Template()
+		tp.Dir("testdata")
+		tp.Root("root")
+		tp.ParseFiles("index", "panic.tmpl")
+		app.Handler(Handler(func(ctx *Context) error {
+			return ctx.View("index", nil)
+		}))
+
+		w := invokeHandler(app, "POST", "/?q=1", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		assert.Contains(t, body, "view 'index' &middot; testdata/panic.tmpl:2:3")
+		assert.Contains(t, body, `<tr class="current"><td class="muted">2</td><td>{{ panic }}</td></tr>`)
+		assert.Contains(t, body, "*hime.TemplateError")
+		assert.Contains(t, body, "runtime/debug.Stack")
+	})
+
+	t.Run("component error", func(t *testing.T) {
+		app := New()
+		app.Dev = true
+		tp := app.Template()
+		tp.ParseComponent("card", "<div>\n{{.A.B}}\n</div>")
+		tp.Parse("index", `{{component "card" .}}`)
+		app.Handler(Handler(func(ctx *Context) error {
+			return ctx.View("index", map[string]any{"A": "x"})
+		}))
+
+		body := invokeHandler(app, "GET", "/", nil).Body.String()
+		assert.Contains(t, body, "view 'index' &middot; index:1:")
+		assert.Contains(t, body, "component 'card' &middot; card:2:")
+		assert.Contains(t, body, `<td class="muted">2</td><td>{{.A.B}}</td>`)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		app := New()
+		app.Dev = true
+		app.Handler(Handler(func(ctx *Context) error {
+			panic("handler panic")
+		}))
+
+		r := httptest.NewRequest("POST", "/", strings.NewReader(url.Values{"name": {"hime"}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("X-Test", "header value")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		body := w.Body.String()
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, body, "panic: handler panic")
+		assert.Contains(t, body, "header value")
+		assert.Contains(t, body, "<th>name</th><td>hime<br></td>")
+	})
+
+	t.Run("abort handler panics", func(t *testing.T) {
+		app := New()
+		app.Dev = true
+		app.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.Panics(t, func() { invokeHandler(app, "GET", "/", nil) })
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		app := New()
+		app.Dev = true
+		app.Handler(Handler(func(ctx *Context) error {
+			return &HTTPError{Status: http.StatusNotFound}
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "Not Found\n", w.Body.String())
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		app := New()
+		assert.False(t, app.Dev)
+		app.ErrorHandler = func(ctx *Context, err error) {
+			ctx.Status(http.StatusInternalServerError).String("handled")
+		}
+		app.Handler(Handler(func(ctx *Context) error {
+			return fmt.Errorf("boom")
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, "handled", w.Body.String())
+
+		app = New()
+		app.Handler(Handler(func(ctx *Context) error {
+			panic("boom")
+		}))
+		assert.Panics(t, func() { invokeHandler(app, "GET", "/", nil) })
+	})
+}
+
+func TestTemplateError(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Template().Component(template.Must(template.New("c").Parse(`{{.A.B}}`)))
+	app.Template().Parse("t", `{{component "c" .}}`)
+
+	err := app.template["t"].Execute(&strings.Builder{}, map[string]any{"A": "x"})
+
+	var te *TemplateError
+	if assert.ErrorAs(t, err, &te) {
+		assert.Equal(t, "t", te.Name)
+		assert.False(t, te.Component)
+		assert.Equal(t, te.Err.Error(), te.Error())
+	}
+
+	// component error is kept in the chain
+	var names []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if te, ok := e.(*TemplateError); ok {
+			names = append(names, te.Name)
+		}
+	}
+	assert.Equal(t, []string{"t", "c"}, names)
+}
@@ -3,6 +3,7 @@ package hime
 import (
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // Errors
@@ -36,6 +37,55 @@ func newErrTemplateNotFound(name string) error {
 	return &ErrTemplateNotFound{name}
 }
 
+// ErrAssetNotFound is the error for asset not found
+type ErrAssetNotFound struct {
+	Name string
+}
+
+func (err *ErrAssetNotFound) Error() string {
+	return fmt.Sprintf("hime: asset '%s' not found", err.Name)
+}
+
+func newErrAssetNotFound(name string) error {
+	return &ErrAssetNotFound{name}
+}
+
+// ErrSanitizePolicyNotFound is the error for sanitize policy not found
+type ErrSanitizePolicyNotFound struct {
+	Name string
+}
+
+func (err *ErrSanitizePolicyNotFound) Error() string {
+	return fmt.Sprintf("hime: sanitize policy '%s' not found", err.Name)
+}
+
+func newErrSanitizePolicyNotFound(name string) error {
+	return &ErrSanitizePolicyNotFound{name}
+}
+
+// ErrLayoutNotFound is the error for layout not found
+type ErrLayoutNotFound struct {
+	Name string
+}
+
+func (err *ErrLayoutNotFound) Error() string {
+	return fmt.Sprintf("hime: layout '%s' not found", err.Name)
+}
+
+func newErrLayoutNotFound(name string) error {
+	return &ErrLayoutNotFound{name}
+}
+
+// ErrFragmentNotFound is the error for fragment not found in a view
+type ErrFragmentNotFound struct {
+	View string
+	Name string
+}
+
+func (err *ErrFragmentNotFound) Error() string {
+	return fmt.Sprintf("hime: fragment '%s' not found in template '%s'", err.Name, err.View)
+}
+
 // ErrTemplateDuplicate is the error for template duplicate
 type ErrTemplateDuplicate struct {
 	Name string
@@ -78,3 +128,63 @@ func (err *ErrComponentDuplicate) Error() string {
 func newErrComponentDuplicate(name string) error {
 	return &ErrComponentDuplicate{name}
 }
+
+// HTTPError is an error carrying the response status and message,
+// return it from a Handler to control the error response
+type HTTPError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func (err *HTTPError) Error() string {
+	msg := err.Message
+	if msg == "" {
+		msg = http.StatusText(err.Status)
+	}
+	if err.Err != nil {
+		return msg + ": " + err.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (err *HTTPError) Unwrap() error {
+	return err.Err
+}
+
+// TemplateError is the error from executing a view or component
+type TemplateError struct {
+	Name      string
+	Component bool
+	Err       error
+
+	sources templateSources
+}
+
+func (err *TemplateError) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (err *TemplateError) Unwrap() error {
+	return err.Err
+}
+
+// StreamError is the error from a streaming view or component
+// which failed after the response was started.
+//
+// The status and part of the body were already sent,
+// so the response can not be replaced by an error page.
+type StreamError struct {
+	Err error
+}
+
+func (err *StreamError) Error() string {
+	return "hime: stream aborted; " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (err *StreamError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,102 @@
+package hime
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrorPages is the map for status code => view name,
+// status code can be an exact code ("404") or a class of codes ("5xx")
+type ErrorPages map[string]string
+
+// ErrorPage is the data passed to an error page view
+type ErrorPage struct {
+	Status  int
+	Message string
+	Request *http.Request
+}
+
+// StatusText returns http.StatusText of the error page's status
+func (p *ErrorPage) StatusText() string {
+	return http.StatusText(p.Status)
+}
+
+// ErrorPages registers error page views,
+// exact status codes take precedence over classes
+func (app *App) ErrorPages(pages ErrorPages) {
+	if app.errorPages == nil {
+		app.errorPages = make(ErrorPages)
+	}
+	mergeErrorPages(app.errorPages, pages)
+}
+
+func mergeErrorPages(dst, src ErrorPages) {
+	for code, view := range src {
+		code = strings.ToLower(code)
+		if !validErrorPageCode(code) {
+			panicf("invalid error page status '%s'", code)
+		}
+		dst[code] = view
+	}
+}
+
+func validErrorPageCode(code string) bool {
+	if len(code) != 3 || code[0] < '1' || code[0] > '5' {
+		return false
+	}
+	if code[1:] == "xx" {
+		return true
+	}
+	return isDigit(code[1]) && isDigit(code[2])
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func cloneErrorPages(xs ErrorPages) ErrorPages {
+	if xs == nil {
+		return nil
+	}
+	rs := make(ErrorPages)
+	for k, v := range xs {
+		rs[k] = v
+	}
+	return rs
+}
+
+// errorPage returns the view name for the status code
+func (app *App) errorPage(code int) (string, bool) {
+	s := strconv.Itoa(code)
+	if name, ok := app.errorPages[s]; ok {
+		return name, true
+	}
+	name, ok := app.errorPages[s[:1]+"xx"]
+	return name, ok
+}
+
+// renderErrorPage renders the error page view for the status code,
+// it returns false when there is no view for the status code or the view
+// fails to render, so the caller can fall back to plain text
+func (ctx *Context) renderErrorPage(code int, message string) bool {
+	name, ok := ctx.app.errorPage(code)
+	if !ok {
+		return false
+	}
+	if _, ok := ctx.app.template[name]; !ok {
+		ctx.app.logf("hime: error page; %v", newErrTemplateNotFound(name))
+		return false
+	}
+
+	err := ctx.Status(code).View(name, &ErrorPage{
+		Status:  code,
+		Message: message,
+		Request: ctx.Request,
+	})
+	if err != nil {
+		ctx.app.logf("hime: error page '%s'; %v", name, err)
+		return false
+	}
+	return true
+}
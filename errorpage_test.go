@@ -0,0 +1,127 @@
+package hime
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorPages(t *testing.T) {
+	t.Parallel()
+
+	newApp := func(h Handler) *App {
+		app := New()
+		app.srv.ErrorLog = log.New(io.Discard, "", 0)
+		app.ParseConfigFile("testdata/errorpage/config.yaml")
+		app.Handler(h)
+		return app
+	}
+
+	t.Run("Config", func(t *testing.T) {
+		app := newApp(nil)
+		assert.Equal(t, ErrorPages{"404": "e404", "5xx": "e5xx", "403": "e404"}, app.errorPages)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return ctx.NotFound()
+		})
+
+		w := invokeHandler(app, "GET", "/missing", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "<h1>404 Not Found</h1><p>/missing</p>", w.Body.String())
+	})
+
+	t.Run("Error with class", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return ctx.Status(http.StatusBadGateway).Error("upstream down")
+		})
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Equal(t, "<h1>502</h1><p>upstream down</p>", w.Body.String())
+	})
+
+	t.Run("Error from TemplateConfig", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return ctx.Status(http.StatusForbidden).Error("forbidden")
+		})
+
+		w := invokeHandler(app, "GET", "/admin", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "<h1>403 Forbidden</h1><p>/admin</p>", w.Body.String())
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return fmt.Errorf("db down")
+		})
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "<h1>500</h1><p>Internal Server Error</p>", w.Body.String())
+	})
+
+	t.Run("fallback to plain text", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return ctx.Status(http.StatusUnauthorized).Error("login required")
+		})
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "login required\n", w.Body.String())
+	})
+
+	t.Run("fallback when view not found", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return ctx.Status(http.StatusUnauthorized).Error("login required")
+		})
+		app.ErrorPages(ErrorPages{"401": "missing"})
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "login required\n", w.Body.String())
+	})
+
+	t.Run("fallback when view errors", func(t *testing.T) {
+		app := newApp(func(ctx *Context) error {
+			return ctx.Status(http.StatusUnauthorized).Error("login required")
+		})
+		app.Template().Parse("bad", "{{.Missing.Field}}")
+		app.ErrorPages(ErrorPages{"4XX": "bad"})
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "login required\n", w.Body.String())
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		for _, code := range []string{"", "40", "4040", "600", "4x", "4+1", "abc"} {
+			assert.Panics(t, func() { New().ErrorPages(ErrorPages{code: "v"}) }, code)
+		}
+	})
+}
+
+func TestErrorPagesLookup(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.ErrorPages(ErrorPages{"404": "a", "4xx": "b"})
+
+	name, ok := app.errorPage(404)
+	assert.True(t, ok)
+	assert.Equal(t, "a", name)
+
+	name, ok = app.errorPage(410)
+	assert.True(t, ok)
+	assert.Equal(t, "b", name)
+
+	_, ok = app.errorPage(500)
+	assert.False(t, ok)
+}
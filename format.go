@@ -0,0 +1,424 @@
+package hime
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatConfig is config for format template funcs, see FormatFuncs
+type FormatConfig struct {
+	// TimeZone is the default time zone, e.g. "Asia/Bangkok", default is UTC
+	TimeZone string `yaml:"timeZone" json:"timeZone"`
+}
+
+// localeFormat is locale's formatting data
+type localeFormat struct {
+	decimal       string
+	group         string
+	currencyAfter bool // currency symbol after the amount
+	date          string
+	time          string
+	months        []string // replaces month names in date when not nil
+	yearOffset    int      // added to year, e.g. Buddhist calendar
+	relative      relativeFormat
+}
+
+// relativeFormat formats relative time, units are second, minute, hour, day, month, year
+type relativeFormat struct {
+	now    string
+	past   string // {n} {unit}
+	future string
+	units  [6][2]string // unit's singular and plural
+}
+
+var enRelative = relativeFormat{
+	now:    "just now",
+	past:   "{n} {unit} ago",
+	future: "in {n} {unit}",
+	units: [6][2]string{
+		{"second", "seconds"}, {"minute", "minutes"}, {"hour", "hours"},
+		{"day", "days"}, {"month", "months"}, {"year", "years"},
+	},
+}
+
+var localeFormats = map[string]*localeFormat{
+	"en": {
+		decimal: ".", group: ",",
+		date: "Jan 2, 2006", time: "3:04 PM",
+		relative: enRelative,
+	},
+	"th": {
+		decimal: ".", group: ",",
+		date: "2 Jan 2006", time: "15:04",
+		months: []string{
+			"ม.ค.", "ก.พ.", "มี.ค.", "เม.ย.", "พ.ค.", "มิ.ย.",
+			"ก.ค.", "ส.ค.", "ก.ย.", "ต.ค.", "พ.ย.", "ธ.ค.",
+		},
+		yearOffset: 543,
+		relative: relativeFormat{
+			now:    "เมื่อสักครู่",
+			past:   "{n} {unit}ที่แล้ว",
+			future: "อีก {n} {unit}",
+			units: [6][2]string{
+				{"วินาที", "วินาที"}, {"นาที", "นาที"}, {"ชั่วโมง", "ชั่วโมง"},
+				{"วัน", "วัน"}, {"เดือน", "เดือน"}, {"ปี", "ปี"},
+			},
+		},
+	},
+	"de": {
+		decimal: ",", group: ".", currencyAfter: true,
+		date: "02.01.2006", time: "15:04",
+		relative: enRelative,
+	},
+	"fr": {
+		decimal: ",", group: " ", currencyAfter: true,
+		date: "02/01/2006", time: "15:04",
+		relative: enRelative,
+	},
+	"ja": {
+		decimal: ".", group: ",",
+		date: "2006/01/02", time: "15:04",
+		relative: enRelative,
+	},
+}
+
+// currencies is currency code's symbol and decimals
+var currencies = map[string]struct {
+	symbol   string
+	decimals int
+}{
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"THB": {"฿", 2},
+	"JPY": {"¥", 0},
+	"CNY": {"¥", 2},
+	"SGD": {"S$", 2},
+}
+
+// FormatFuncs registers locale-aware format template funcs, must call before load templates.
+//
+//	{{number 1234.5}}                  1,234.5
+//	{{number 1234.5 2}}                1,234.50
+//	{{currency 1234.5 "THB"}}          ฿1,234.50
+//	{{percent 0.125 1}}                12.5%
+//	{{date .T}} {{time .T}}            Jan 2, 2006 3:04 PM
+//	{{datetime .T "2006-01-02 15:04"}}
+//	{{timeAgo .T}}                     3 minutes ago
+//	{{bytes 1536}}                     1.5 KB
+//	{{plural .N "item" "items"}}       items
+//
+// Funcs use the request's locale (see Context.Locale) and time zone (see Context.TimeZone),
+// or the default locale and time zone when render without context.
+func (app *App) FormatFuncs(cfg FormatConfig) {
+	loc := time.UTC
+	if cfg.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			panicf("load time zone; %v", err)
+		}
+	}
+	app.timeZone = loc
+
+	fallback := &formatter{app: app}
+	for name, fn := range formatFuncs {
+		app.contextFallbacks[name] = fn(fallback)
+		app.ContextFunc(name, func(ctx *Context) any {
+			return fn(&formatter{app: ctx.app, ctx: ctx})
+		})
+	}
+}
+
+// TimeZone returns the request's time zone,
+// set by SetTimeZone or the default time zone of FormatFuncs
+func (ctx *Context) TimeZone() *time.Location {
+	if ctx.timeZone != nil {
+		return ctx.timeZone
+	}
+	if ctx.app.timeZone != nil {
+		return ctx.app.timeZone
+	}
+	return time.UTC
+}
+
+// SetTimeZone overrides the request's time zone
+func (ctx *Context) SetTimeZone(loc *time.Location) *Context {
+	ctx.timeZone = loc
+	return ctx
+}
+
+// formatter formats values for the context's locale, ctx can be nil
+type formatter struct {
+	app *App
+	ctx *Context
+}
+
+var formatFuncs = map[string]func(f *formatter) any{
+	"number":   func(f *formatter) any { return f.Number },
+	"currency": func(f *formatter) any { return f.Currency },
+	"percent":  func(f *formatter) any { return f.Percent },
+	"date":     func(f *formatter) any { return f.Date },
+	"time":     func(f *formatter) any { return f.Time },
+	"datetime": func(f *formatter) any { return f.DateTime },
+	"timeAgo":  func(f *formatter) any { return f.TimeAgo },
+	"bytes":    func(f *formatter) any { return f.Bytes },
+	"plural":   func(f *formatter) any { return f.Plural },
+}
+
+func (f *formatter) locale() string {
+	if f.ctx != nil {
+		return f.ctx.Locale()
+	}
+	return f.app.i18n.defaultLocale
+}
+
+func (f *formatter) format() *localeFormat {
+	locale := f.locale()
+	if x, ok := localeFormats[locale]; ok {
+		return x
+	}
+	if x, ok := localeFormats[localeLang(locale)]; ok {
+		return x
+	}
+	return localeFormats["en"]
+}
+
+func (f *formatter) timeZone() *time.Location {
+	if f.ctx != nil {
+		return f.ctx.TimeZone()
+	}
+	if f.app.timeZone != nil {
+		return f.app.timeZone
+	}
+	return time.UTC
+}
+
+// Number formats number with locale's separators, decimals is optional
+func (f *formatter) Number(v any, decimals ...int) (string, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("hime: number: invalid number %T", v)
+	}
+	d := -1
+	if len(decimals) > 0 {
+		d = decimals[0]
+	}
+	return formatNumber(f.format(), n, d), nil
+}
+
+// Currency formats amount with currency code's symbol
+func (f *formatter) Currency(v any, code string) (string, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("hime: currency: invalid number %T", v)
+	}
+	code = strings.ToUpper(code)
+	c, ok := currencies[code]
+	if !ok {
+		c.symbol, c.decimals = code+" ", 2
+	}
+
+	lf := f.format()
+	s := formatNumber(lf, math.Abs(n), c.decimals)
+	if lf.currencyAfter {
+		s = s + " " + strings.TrimSpace(c.symbol)
+	} else {
+		s = c.symbol + s
+	}
+	if n < 0 {
+		s = "-" + s
+	}
+	return s, nil
+}
+
+// Percent formats ratio as percentage, e.g. 0.125 is 12.5%
+func (f *formatter) Percent(v any, decimals ...int) (string, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("hime: percent: invalid number %T", v)
+	}
+	d := 0
+	if len(decimals) > 0 {
+		d = decimals[0]
+	}
+	return formatNumber(f.format(), n*100, d) + "%", nil
+}
+
+// Date formats date in the time zone, layout is optional
+func (f *formatter) Date(v any, layout ...string) (string, error) {
+	return f.formatTime("date", v, f.format().date, layout)
+}
+
+// Time formats time of day in the time zone, layout is optional
+func (f *formatter) Time(v any, layout ...string) (string, error) {
+	return f.formatTime("time", v, f.format().time, layout)
+}
+
+// DateTime formats date and time in the time zone, layout is optional
+func (f *formatter) DateTime(v any, layout ...string) (string, error) {
+	lf := f.format()
+	return f.formatTime("datetime", v, lf.date+" "+lf.time, layout)
+}
+
+func (f *formatter) formatTime(name string, v any, def string, layout []string) (string, error) {
+	t, ok := toTime(v)
+	if !ok {
+		return "", fmt.Errorf("hime: %s: invalid time %T", name, v)
+	}
+	if t.IsZero() {
+		return "", nil
+	}
+	if len(layout) > 0 {
+		def = layout[0]
+	}
+	return formatTime(f.format(), t.In(f.timeZone()), def), nil
+}
+
+// TimeAgo formats time relative to now, e.g. "3 minutes ago", "in 2 days"
+func (f *formatter) TimeAgo(v any) (string, error) {
+	t, ok := toTime(v)
+	if !ok {
+		return "", fmt.Errorf("hime: timeAgo: invalid time %T", v)
+	}
+	return formatRelative(f.format().relative, time.Since(t)), nil
+}
+
+// Bytes formats byte size, e.g. 1.5 MB
+func (f *formatter) Bytes(v any) (string, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("hime: bytes: invalid number %T", v)
+	}
+
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for math.Abs(n) >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	d := 1
+	if i == 0 || n == math.Trunc(n) {
+		d = 0
+	}
+	return formatNumber(f.format(), n, d) + " " + units[i], nil
+}
+
+// Plural returns the form for n by the locale's plural rule,
+// forms are "one" and "other" forms, or forms for "zero", "one", "two", "few", "many", "other"
+func (f *formatter) Plural(v any, forms ...string) (string, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("hime: plural: invalid number %T", v)
+	}
+	if len(forms) == 0 {
+		return "", fmt.Errorf("hime: plural: no forms")
+	}
+
+	category := f.app.pluralRule(f.locale())(n)
+	if len(forms) == 2 {
+		if category == "one" {
+			return forms[0], nil
+		}
+		return forms[1], nil
+	}
+	for i, c := range []string{"zero", "one", "two", "few", "many", "other"} {
+		if c == category && i < len(forms) {
+			return forms[i], nil
+		}
+	}
+	return forms[len(forms)-1], nil
+}
+
+// formatNumber formats n with decimals, -1 decimals uses the smallest number of digits
+func formatNumber(lf *localeFormat, n float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if n < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteString("-")
+	}
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(lf.group)
+		}
+		b.WriteRune(c)
+	}
+	if fracPart != "" {
+		b.WriteString(lf.decimal)
+		b.WriteString(fracPart)
+	}
+	return b.String()
+}
+
+// formatTime formats t with locale's month names and calendar year,
+// they are replaced with placeholders since they can not be layout
+func formatTime(lf *localeFormat, t time.Time, layout string) string {
+	var rs []string
+	if lf.yearOffset != 0 && strings.Contains(layout, "2006") {
+		layout = strings.ReplaceAll(layout, "2006", "\x00")
+		rs = append(rs, "\x00", strconv.Itoa(t.Year()+lf.yearOffset))
+	}
+	if lf.months != nil && strings.Contains(layout, "Jan") && !strings.Contains(layout, "January") {
+		layout = strings.ReplaceAll(layout, "Jan", "\x01")
+		rs = append(rs, "\x01", lf.months[t.Month()-1])
+	}
+	if len(rs) == 0 {
+		return t.Format(layout)
+	}
+	return strings.NewReplacer(rs...).Replace(t.Format(layout))
+}
+
+func formatRelative(rf relativeFormat, d time.Duration) string {
+	format := rf.past
+	if d < 0 {
+		d = -d
+		format = rf.future
+	}
+	if d < 10*time.Second {
+		return rf.now
+	}
+
+	var n int64
+	var unit int
+	switch {
+	case d < time.Minute:
+		n, unit = int64(d/time.Second), 0
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), 1
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), 2
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), 3
+	case d < 365*24*time.Hour:
+		n, unit = int64(d/(30*24*time.Hour)), 4
+	default:
+		n, unit = int64(d/(365*24*time.Hour)), 5
+	}
+
+	name := rf.units[unit][1]
+	if n == 1 {
+		name = rf.units[unit][0]
+	}
+	return formatParams(format, map[string]string{
+		"n":    strconv.FormatInt(n, 10),
+		"unit": name,
+	})
+}
+
+func toTime(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, true
+		}
+		return *v, true
+	}
+	return time.Time{}, false
+}
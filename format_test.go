@@ -0,0 +1,134 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFuncs(t *testing.T) {
+	t.Parallel()
+
+	tm := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+
+	newApp := func() *App {
+		app := New()
+		app.I18n(I18nConfig{Default: "en"})
+		app.Catalog("en", map[string]any{})
+		app.Catalog("th", map[string]any{})
+		app.Catalog("de", map[string]any{})
+		app.FormatFuncs(FormatConfig{TimeZone: "Asia/Bangkok"})
+		app.Template().Parse("page", `{{number .N}}|{{number .N 2}}|{{currency .N "THB"}}|{{percent 0.125 1}}|{{date .T}}|{{time .T}}|{{datetime .T "2006-01-02 15:04"}}|{{bytes 1536}}|{{plural 1 "item" "items"}}`)
+		return app
+	}
+	render := func(app *App, lang string, f func(ctx *Context)) string {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		ctx := NewAppContext(app, w, r)
+		if f != nil {
+			f(ctx)
+		}
+		assert.NoError(t, ctx.View("page", map[string]any{"N": -1234567.891, "T": tm}))
+		return w.Body.String()
+	}
+
+	t.Run("en", func(t *testing.T) {
+		assert.Equal(t,
+			"-1,234,567.891|-1,234,567.89|-฿1,234,567.89|12.5%|Mar 5, 2024|9:30 PM|2024-03-05 21:30|1.5 KB|item",
+			render(newApp(), "en", nil))
+	})
+
+	t.Run("th", func(t *testing.T) {
+		assert.Equal(t,
+			"-1,234,567.891|-1,234,567.89|-฿1,234,567.89|12.5%|5 มี.ค. 2567|21:30|2567-03-05 21:30|1.5 KB|items",
+			render(newApp(), "th", nil))
+	})
+
+	t.Run("de with time zone", func(t *testing.T) {
+		assert.Equal(t,
+			"-1.234.567,891|-1.234.567,89|-1.234.567,89 ฿|12,5%|05.03.2024|14:30|2024-03-05 14:30|1,5 KB|item",
+			render(newApp(), "de", func(ctx *Context) { ctx.SetTimeZone(time.UTC) }))
+	})
+
+	t.Run("without context", func(t *testing.T) {
+		app := newApp()
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "page", map[string]any{"N": 1, "T": tm}))
+		assert.Equal(t, "1|1.00|฿1.00|12.5%|Mar 5, 2024|9:30 PM|2024-03-05 21:30|1.5 KB|item", b.String())
+	})
+
+	t.Run("clone without context", func(t *testing.T) {
+		app := newApp().Clone()
+		app.Template().Parse("cloned", `{{number .N 2}}|{{date .T}}`)
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "page", map[string]any{"N": 1, "T": tm}))
+		assert.Equal(t, "1|1.00|฿1.00|12.5%|Mar 5, 2024|9:30 PM|2024-03-05 21:30|1.5 KB|item", b.String())
+
+		// pooled templates are reset to the fallbacks after render
+		for range 2 {
+			b.Reset()
+			assert.NoError(t, app.RenderView(&b, "cloned", map[string]any{"N": 1, "T": tm}))
+			assert.Equal(t, "1.00|Mar 5, 2024", b.String())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		app := newApp()
+		app.Template().Parse("bad", `{{number "x"}}`)
+		assert.Error(t, app.RenderView(&strings.Builder{}, "bad", nil))
+	})
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	en := localeFormats["en"]
+	f := &formatter{app: New()}
+
+	assert.Equal(t, "0", formatNumber(en, -0.001, 0))
+	assert.Equal(t, "999", formatNumber(en, 999, -1))
+	assert.Equal(t, "1,000", formatNumber(en, 1000, -1))
+
+	for _, c := range []struct {
+		in  any
+		out string
+	}{
+		{0, "0 B"}, {1023, "1,023 B"}, {1024, "1 KB"}, {int64(5 << 30), "5 GB"},
+	} {
+		s, err := f.Bytes(c.in)
+		assert.NoError(t, err)
+		assert.Equal(t, c.out, s)
+	}
+
+	s, _ := f.Currency(1234, "JPY")
+	assert.Equal(t, "¥1,234", s)
+	s, _ = f.Currency(5, "xyz")
+	assert.Equal(t, "XYZ 5.00", s)
+
+	s, _ = f.Plural(5, "zero", "one", "two", "few", "many", "other")
+	assert.Equal(t, "other", s)
+
+	for _, c := range []struct {
+		d   time.Duration
+		out string
+	}{
+		{time.Second, "just now"},
+		{30 * time.Second, "30 seconds ago"},
+		{time.Minute, "1 minute ago"},
+		{-3 * time.Hour, "in 3 hours"},
+		{49 * time.Hour, "2 days ago"},
+		{400 * 24 * time.Hour, "1 year ago"},
+	} {
+		assert.Equal(t, c.out, formatRelative(en.relative, c.d))
+	}
+	assert.Equal(t, "อีก 2 วัน", formatRelative(localeFormats["th"].relative, -49*time.Hour))
+
+	s, err := f.TimeAgo(time.Now().Add(-5 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, "5 minutes ago", s)
+}
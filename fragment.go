@@ -0,0 +1,75 @@
+package hime
+
+import (
+	"bytes"
+	"io"
+)
+
+// ViewFragment renders only the named template (a {{define}} or {{block}})
+// from the view, so a view can serve both full page and partial responses.
+//
+// It panics if the view is not found, matching View,
+// and returns *ErrFragmentNotFound if the view does not have the fragment.
+func (ctx *Context) ViewFragment(view, name string, data any) error {
+	buf := getBytes()
+	defer putBytes(buf)
+
+	err := ctx.executeFragment(buf, view, name, data)
+	if err != nil {
+		return err
+	}
+
+	return ctx.writeHTML(buf)
+}
+
+// RenderFragmentToString renders the named template from the view to a string
+// instead of writing it to the response, see ViewFragment.
+func (ctx *Context) RenderFragmentToString(view, name string, data any) (string, error) {
+	buf := getBytes()
+	defer putBytes(buf)
+
+	err := ctx.executeFragment(buf, view, name, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (ctx *Context) executeFragment(buf *bytes.Buffer, view, name string, data any) error {
+	data = ctx.withViewData(data)
+
+	t, ok := ctx.app.lookupTemplate(view)
+	if !ok {
+		panic(newErrTemplateNotFound(view))
+	}
+	return t.ExecuteFragment(ctx, buf, name, data)
+}
+
+// ExecuteFragment executes the named template from t's set
+// with context funcs bound to ctx
+func (t *tmpl) ExecuteFragment(ctx *Context, w io.Writer, name string, data any) error {
+	c := t.c.Load()
+	x := c.t.Lookup(name)
+	if x == nil || x.Tree == nil {
+		return &ErrFragmentNotFound{View: t.name, Name: name}
+	}
+
+	buf := getBytes()
+	defer putBytes(buf)
+
+	err := c.run(ctx, buf, name, data)
+	if err != nil {
+		return &TemplateError{
+			Name:      t.name,
+			Component: t.component,
+			Err:       err,
+			sources:   t.sources,
+		}
+	}
+
+	if c.m == nil {
+		_, err = buf.WriteTo(w)
+		return err
+	}
+	return c.m.Minify("text/html", w, buf)
+}
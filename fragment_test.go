@@ -0,0 +1,91 @@
+package hime
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewFragment(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		tp := app.Template()
+		tp.Minify()
+		tp.Parse("list", `<html><body>
+  <h1>  Users  </h1>
+  {{block "rows" .}}
+    <ul>  {{range .}}<li>  {{.}}  </li>{{end}}  </ul>
+  {{end}}
+</body></html>`)
+		return app
+	}
+	newContext := func(app *App) (*Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		return NewAppContext(app, w, r), w
+	}
+	data := []string{"a", "b<"}
+
+	t.Run("renders fragment", func(t *testing.T) {
+		ctx, w := newContext(newApp())
+		ctx.ETag(true)
+		assert.NoError(t, ctx.ViewFragment("list", "rows", data))
+		assert.Equal(t, "<ul><li>a<li>b&lt;</ul>", w.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+	})
+
+	t.Run("full view still renders", func(t *testing.T) {
+		app := newApp()
+		ctx, _ := newContext(app)
+		_, err := ctx.RenderFragmentToString("list", "rows", data)
+		assert.NoError(t, err)
+
+		ctx, w := newContext(app)
+		assert.NoError(t, ctx.View("list", data))
+		assert.Contains(t, w.Body.String(), "<h1>Users</h1>")
+		assert.Contains(t, w.Body.String(), "<li>b&lt;</ul>")
+	})
+
+	t.Run("to string", func(t *testing.T) {
+		ctx, w := newContext(newApp())
+		s, err := ctx.RenderFragmentToString("list", "rows", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "<ul><li>a<li>b&lt;</ul>", s)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("fragment not found", func(t *testing.T) {
+		ctx, w := newContext(newApp())
+		err := ctx.ViewFragment("list", "cols", data)
+
+		var notFound *ErrFragmentNotFound
+		if assert.True(t, errors.As(err, &notFound)) {
+			assert.Equal(t, "list", notFound.View)
+			assert.Equal(t, "cols", notFound.Name)
+		}
+		assert.EqualError(t, err, "hime: fragment 'cols' not found in template 'list'")
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("view not found", func(t *testing.T) {
+		ctx, _ := newContext(newApp())
+		assert.Panics(t, func() {
+			ctx.ViewFragment("notfound", "rows", data)
+		})
+	})
+
+	t.Run("execute error", func(t *testing.T) {
+		ctx, w := newContext(newApp())
+		err := ctx.ViewFragment("list", "rows", struct{}{})
+
+		var templateErr *TemplateError
+		assert.True(t, errors.As(err, &templateErr))
+		assert.Empty(t, w.Body.String())
+	})
+}
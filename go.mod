@@ -6,6 +6,7 @@ require (
 	github.com/moonrhythm/parapet v0.17.2
 	github.com/stretchr/testify v1.11.1
 	github.com/tdewolff/minify/v2 v2.24.13
+	github.com/tdewolff/parse/v2 v2.8.13
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -14,6 +15,5 @@ require (
 	github.com/kavu/go_reuseport v1.5.0 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
-	github.com/tdewolff/parse/v2 v2.8.13 // indirect
 	gopkg.in/check.v1 v1.0.0-20200902074654-038fdea0a05b // indirect
 )
@@ -4,18 +4,94 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"runtime/debug"
 )
 
 // Handler is the hime handler
 type Handler func(*Context) error
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	err := h(NewContext(w, r))
+	ctx := NewContext(w, r)
+	err := h(ctx)
 
 	switch {
 	case err == nil:
 	case errors.Is(err, context.Canceled):
 	default:
-		panic(err)
+		ctx.app.handleError(ctx, err)
 	}
 }
+
+// ErrorHandler handles a non-nil error returned from a Handler.
+// It picks the response status and writes the response.
+// A *StreamError is logged without calling the ErrorHandler.
+type ErrorHandler func(ctx *Context, err error)
+
+func (app *App) handleError(ctx *Context, err error) {
+	// the response was started, error body would corrupt the partial body
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		app.logf("hime: handler error; %v", err)
+		return
+	}
+
+	if app.Dev && isDevError(err) {
+		writeDevError(ctx.w, ctx.Request, err, debug.Stack())
+		return
+	}
+
+	h := app.ErrorHandler
+	if h == nil {
+		h = DefaultErrorHandler
+	}
+	h(ctx, err)
+}
+
+// DefaultErrorHandler is the ErrorHandler used when App.ErrorHandler is nil.
+//
+// A *Problem in err's chain is written as a problem document.
+// An *HTTPError in err's chain selects the status and message;
+// any other error responds with the context's error status
+// (500 unless a 4xx/5xx status was set) and its status text,
+// so internal error messages are never sent to the client.
+// When the request prefers json, the response is a problem document.
+// Errors resulting in a 5xx status are logged to the server's ErrorLog.
+// A *StreamError is only logged, since the response was already started.
+func DefaultErrorHandler(ctx *Context, err error) {
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		ctx.app.logf("hime: handler error; %v", err)
+		return
+	}
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		if problem.Status >= 500 {
+			ctx.app.logf("hime: handler error; %v", err)
+		}
+		ctx.Problem(problem)
+		return
+	}
+
+	var msg string
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.Status != 0 {
+			ctx.Status(httpErr.Status)
+		}
+		msg = httpErr.Message
+	}
+	status := ctx.statusCodeError()
+	if status >= 500 {
+		ctx.app.logf("hime: handler error; %v", err)
+	}
+
+	if ctx.PrefersJSON() {
+		ctx.Problem(&Problem{Status: status, Detail: msg})
+		return
+	}
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	ctx.Error(msg)
+}
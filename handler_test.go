@@ -3,6 +3,8 @@ package hime
 import (
 	"context"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,15 +15,66 @@ import (
 func TestHandler(t *testing.T) {
 	t.Parallel()
 
-	t.Run("panic on error", func(t *testing.T) {
+	t.Run("error responds 500", func(t *testing.T) {
 		app := New()
+		app.srv.ErrorLog = log.New(io.Discard, "", 0)
 		app.Handler(Handler(func(ctx *Context) error {
-			return fmt.Errorf("must panic")
+			return fmt.Errorf("internal detail")
 		}))
 
-		assert.Panics(t, func() {
-			invokeHandler(app, "GET", "/", nil)
-		})
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "Internal Server Error\n", w.Body.String())
+	})
+
+	t.Run("error keeps error status", func(t *testing.T) {
+		app := New()
+		app.Handler(Handler(func(ctx *Context) error {
+			ctx.Status(http.StatusForbidden)
+			return fmt.Errorf("denied")
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "Forbidden\n", w.Body.String())
+	})
+
+	t.Run("HTTPError", func(t *testing.T) {
+		app := New()
+		app.Handler(Handler(func(ctx *Context) error {
+			return &HTTPError{Status: http.StatusNotFound, Message: "no such user"}
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "no such user\n", w.Body.String())
+	})
+
+	t.Run("wrapped HTTPError without message", func(t *testing.T) {
+		app := New()
+		app.Handler(Handler(func(ctx *Context) error {
+			return fmt.Errorf("load: %w", &HTTPError{Status: http.StatusBadRequest})
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "Bad Request\n", w.Body.String())
+	})
+
+	t.Run("custom ErrorHandler", func(t *testing.T) {
+		wantErr := fmt.Errorf("boom")
+		app := New()
+		app.ErrorHandler = func(ctx *Context, err error) {
+			assert.Equal(t, wantErr, err)
+			ctx.Status(http.StatusTeapot).String("handled")
+		}
+		app.Handler(Handler(func(ctx *Context) error {
+			return wantErr
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusTeapot, w.Code)
+		assert.Equal(t, "handled", w.Body.String())
 	})
 
 	t.Run("net/http", func(t *testing.T) {
@@ -76,22 +129,29 @@ func TestHandler(t *testing.T) {
 		})
 	})
 
-	t.Run("context.DeadlineExceeded panics", func(t *testing.T) {
+	t.Run("context.DeadlineExceeded is handled", func(t *testing.T) {
 		// Only context.Canceled is swallowed; DeadlineExceeded is treated
-		// as a real error and panics.
+		// as a real error and goes to the error handler.
 		app := New()
+		called := false
+		app.ErrorHandler = func(ctx *Context, err error) {
+			called = true
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}
 		app.Handler(Handler(func(ctx *Context) error {
 			return context.DeadlineExceeded
 		}))
 
-		assert.Panics(t, func() {
-			invokeHandler(app, "GET", "/", nil)
-		})
+		invokeHandler(app, "GET", "/", nil)
+		assert.True(t, called)
 	})
 
-	t.Run("panic value is the returned error", func(t *testing.T) {
+	t.Run("ErrorHandler can panic", func(t *testing.T) {
 		wantErr := fmt.Errorf("boom")
 		app := New()
+		app.ErrorHandler = func(ctx *Context, err error) {
+			panic(err)
+		}
 		app.Handler(Handler(func(ctx *Context) error {
 			return wantErr
 		}))
@@ -103,3 +163,15 @@ func TestHandler(t *testing.T) {
 		t.Fatal("expected panic")
 	})
 }
+
+func TestHTTPError(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Not Found", (&HTTPError{Status: http.StatusNotFound}).Error())
+	assert.Equal(t, "gone", (&HTTPError{Status: http.StatusNotFound, Message: "gone"}).Error())
+
+	inner := fmt.Errorf("db down")
+	err := &HTTPError{Status: http.StatusServiceUnavailable, Err: inner}
+	assert.Equal(t, "Service Unavailable: db down", err.Error())
+	assert.ErrorIs(t, err, inner)
+}
@@ -0,0 +1,567 @@
+package hime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// I18nConfig is i18n config
+//
+// Example:
+//
+//	i18n:
+//	  default: en
+//	  cookie: lang
+//	  prefix: true
+//	  fallback:
+//	    th-TH: [th]
+//	  catalogs:
+//	    en: [locales/en.yaml]
+//	    th: [locales/th.yaml]
+type I18nConfig struct {
+	// Default is the locale used when the request does not select any locale,
+	// and the last locale of every fallback chain
+	Default string `yaml:"default" json:"default"`
+
+	// Fallback is locale's fallback locales before the default locale
+	Fallback map[string][]string `yaml:"fallback" json:"fallback"`
+
+	// Catalogs is locale's message files, in yaml or json
+	Catalogs map[string][]string `yaml:"catalogs" json:"catalogs"`
+
+	// Cookie is the cookie name which selects the locale
+	Cookie string `yaml:"cookie" json:"cookie"`
+
+	// Prefix selects the locale from the first path segment, e.g. /th/about,
+	// and generates routes with the locale's prefix, see Context.Route
+	Prefix bool `yaml:"prefix" json:"prefix"`
+}
+
+// PluralRule returns plural category of n,
+// one of "zero", "one", "two", "few", "many", "other"
+type PluralRule func(n float64) string
+
+// pluralForms are message's keys for plural categories
+var pluralForms = map[string]bool{
+	"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true,
+}
+
+type i18n struct {
+	defaultLocale string
+	fallback      map[string][]string
+	cookie        string
+	prefix        bool
+	catalogs      map[string]map[string]message // locale => key => message
+	plurals       map[string]PluralRule
+}
+
+// message is a catalog's message, plural is the message's plural forms
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+type ctxKeyLocale struct{}
+
+// I18n loads i18n config, must call before load templates when Prefix is enabled
+func (app *App) I18n(cfg I18nConfig) {
+	if cfg.Default != "" {
+		app.i18n.defaultLocale = cfg.Default
+	}
+	for locale, xs := range cfg.Fallback {
+		if app.i18n.fallback == nil {
+			app.i18n.fallback = make(map[string][]string)
+		}
+		app.i18n.fallback[locale] = xs
+	}
+	if cfg.Cookie != "" {
+		app.i18n.cookie = cfg.Cookie
+	}
+	if cfg.Prefix && !app.i18n.prefix {
+		app.i18n.prefix = true
+		app.ContextFunc("route", func(ctx *Context) any {
+			return ctx.Route
+		})
+	}
+	for locale, filenames := range cfg.Catalogs {
+		for _, filename := range filenames {
+			app.ParseCatalogFile(locale, filename)
+		}
+	}
+}
+
+// Catalog registers locale's messages,
+// nested maps are flatten into keys joined with ".",
+// a map of plural categories (see PluralRule) is a plural message.
+//
+// Messages can have named params, e.g. "Hello, {name}",
+// and plural messages get the count as {count}.
+func (app *App) Catalog(locale string, messages map[string]any) {
+	if app.i18n.catalogs == nil {
+		app.i18n.catalogs = make(map[string]map[string]message)
+	}
+	c := app.i18n.catalogs[locale]
+	if c == nil {
+		c = make(map[string]message)
+		app.i18n.catalogs[locale] = c
+	}
+	addMessages(c, "", messages)
+}
+
+// ParseCatalog parses locale's messages from yaml or json data, see Catalog
+func (app *App) ParseCatalog(locale string, data []byte) {
+	var messages map[string]any
+	err := yaml.Unmarshal(data, &messages)
+	if err != nil {
+		panicf("can not parse catalog '%s'; %v", locale, err)
+	}
+	app.Catalog(locale, messages)
+}
+
+// ParseCatalogFile parses locale's messages from file, see Catalog
+func (app *App) ParseCatalogFile(locale string, filename string) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		panicf("read catalog file; %v", err)
+	}
+	app.ParseCatalog(locale, data)
+}
+
+// PluralRule sets plural rule for the language, e.g. "th" or "en-US"
+func (app *App) PluralRule(lang string, rule PluralRule) {
+	if app.i18n.plurals == nil {
+		app.i18n.plurals = make(map[string]PluralRule)
+	}
+	app.i18n.plurals[lang] = rule
+}
+
+// Locales returns locales which have catalog
+func (app *App) Locales() []string {
+	rs := make([]string, 0, len(app.i18n.catalogs))
+	for locale := range app.i18n.catalogs {
+		rs = append(rs, locale)
+	}
+	sort.Strings(rs)
+	return rs
+}
+
+// T translates message's key into the locale's message.
+//
+// The first number in args is the count which selects the plural form,
+// *Param, map[string]any and map[string]string in args are named params.
+// It returns the key when no locale in the fallback chain has the message.
+func (app *App) T(locale string, key string, args ...any) string {
+	for _, l := range app.localeChain(locale) {
+		m, ok := app.i18n.catalogs[l][key]
+		if ok {
+			return app.formatMessage(l, m, args)
+		}
+	}
+	return key
+}
+
+func addMessages(c map[string]message, prefix string, xs map[string]any) {
+	for k, v := range xs {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			if plural, ok := pluralMessage(v); ok {
+				c[key] = message{plural: plural}
+				continue
+			}
+			addMessages(c, key, v)
+		case nil:
+		default:
+			c[key] = message{text: fmt.Sprint(v)}
+		}
+	}
+}
+
+func pluralMessage(xs map[string]any) (map[string]string, bool) {
+	rs := make(map[string]string, len(xs))
+	for k, v := range xs {
+		s, ok := v.(string)
+		if !ok || !pluralForms[k] {
+			return nil, false
+		}
+		rs[k] = s
+	}
+	return rs, len(rs) > 0
+}
+
+// localeChain returns locales to lookup messages,
+// the locale, its fallback locales, its language, then the default locale
+func (app *App) localeChain(locale string) []string {
+	rs := make([]string, 0, 4)
+	add := func(l string) {
+		if l == "" {
+			return
+		}
+		for _, x := range rs {
+			if x == l {
+				return
+			}
+		}
+		rs = append(rs, l)
+	}
+
+	add(locale)
+	for _, l := range app.i18n.fallback[locale] {
+		add(l)
+	}
+	add(localeLang(locale))
+	add(app.i18n.defaultLocale)
+	return rs
+}
+
+func (app *App) formatMessage(locale string, m message, args []any) string {
+	var params map[string]string
+	setParam := func(k string, v any) {
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[k] = fmt.Sprint(v)
+	}
+
+	count, hasCount := 0.0, false
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case *Param:
+			setParam(v.Name, v.Value)
+		case map[string]any:
+			for k, x := range v {
+				setParam(k, x)
+			}
+		case map[string]string:
+			for k, x := range v {
+				setParam(k, x)
+			}
+		default:
+			if n, ok := toFloat(arg); ok && !hasCount {
+				count, hasCount = n, true
+				setParam("count", arg)
+			}
+		}
+	}
+
+	text := m.text
+	if m.plural != nil {
+		text = m.plural[app.pluralRule(locale)(count)]
+		if count == 0 && m.plural["zero"] != "" {
+			text = m.plural["zero"]
+		}
+		if text == "" {
+			text = m.plural["other"]
+		}
+	}
+	return formatParams(text, params)
+}
+
+// formatParams replaces {name} with params
+func formatParams(s string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			break
+		}
+		v, ok := params[s[i+1:i+j]]
+		if !ok {
+			b.WriteString(s[:i+j+1])
+			s = s[i+j+1:]
+			continue
+		}
+		b.WriteString(s[:i])
+		b.WriteString(v)
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func (app *App) pluralRule(locale string) PluralRule {
+	if r, ok := app.i18n.plurals[locale]; ok {
+		return r
+	}
+	lang := localeLang(locale)
+	if r, ok := app.i18n.plurals[lang]; ok {
+		return r
+	}
+	if r, ok := defaultPluralRules[lang]; ok {
+		return r
+	}
+	return pluralOne
+}
+
+func pluralOne(n float64) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func pluralOther(float64) string {
+	return "other"
+}
+
+// pluralZeroOne is the rule for languages which 0 and 1 are singular, e.g. French
+func pluralZeroOne(n float64) string {
+	if n >= 0 && n < 2 {
+		return "one"
+	}
+	return "other"
+}
+
+// pluralSlavic is the rule for Russian and Ukrainian
+func pluralSlavic(n float64) string {
+	if n != float64(int64(n)) {
+		return "other"
+	}
+	i := int64(n)
+	if i < 0 {
+		i = -i
+	}
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return "one"
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+var defaultPluralRules = map[string]PluralRule{
+	"th": pluralOther, "ja": pluralOther, "zh": pluralOther, "ko": pluralOther,
+	"vi": pluralOther, "id": pluralOther, "ms": pluralOther, "lo": pluralOther,
+	"fr": pluralZeroOne,
+	"ru": pluralSlavic, "uk": pluralSlavic,
+}
+
+// localeLang returns the language of the locale, e.g. "th" for "th-TH"
+func localeLang(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// matchLocale returns supported locale for the requested locale,
+// matching the locale, then the language, case-insensitive
+func (app *App) matchLocale(locale string) string {
+	if locale == "" {
+		return ""
+	}
+	locale = strings.ReplaceAll(locale, "_", "-")
+
+	var langMatch string
+	lang := localeLang(locale)
+	for l := range app.i18n.catalogs {
+		if strings.EqualFold(l, locale) {
+			return l
+		}
+		if langMatch == "" && strings.EqualFold(localeLang(l), lang) {
+			langMatch = l
+		}
+	}
+	if langMatch == "" && strings.EqualFold(app.i18n.defaultLocale, locale) {
+		return app.i18n.defaultLocale
+	}
+	return langMatch
+}
+
+// splitLocalePrefix returns the locale in the path's first segment and the remaining path
+func (app *App) splitLocalePrefix(p string) (locale string, rest string) {
+	if !strings.HasPrefix(p, "/") {
+		return "", p
+	}
+	seg, rest, _ := strings.Cut(p[1:], "/")
+	rest = "/" + rest
+	for l := range app.i18n.catalogs {
+		if l == seg {
+			return l, rest
+		}
+	}
+	return "", p
+}
+
+// stripLocalePrefix removes the locale prefix from the request's path,
+// and stores the locale into the request's context
+func (app *App) stripLocalePrefix(r *http.Request) *http.Request {
+	locale, rest := app.splitLocalePrefix(r.URL.Path)
+	if locale == "" {
+		return r
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), ctxKeyLocale{}, locale))
+	u := *r.URL
+	u.Path = rest
+	u.RawPath = ""
+	r.URL = &u
+	return r
+}
+
+// acceptLanguages returns languages from Accept-Language header sorted by quality
+func acceptLanguages(s string) []string {
+	type lang struct {
+		tag string
+		q   float64
+	}
+
+	var xs []lang
+	for _, part := range strings.Split(s, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		xs = append(xs, lang{tag, q})
+	}
+	sort.SliceStable(xs, func(i, j int) bool { return xs[i].q > xs[j].q })
+
+	rs := make([]string, len(xs))
+	for i, x := range xs {
+		rs[i] = x.tag
+	}
+	return rs
+}
+
+// Locale returns the request's locale, resolved from
+// SetLocale, the route prefix, the cookie, Accept-Language header,
+// then the default locale
+func (ctx *Context) Locale() string {
+	if ctx.locale != "" {
+		return ctx.locale
+	}
+
+	app := ctx.app
+	locale := ""
+	if app.i18n.prefix {
+		locale, _ = ctx.Value(ctxKeyLocale{}).(string)
+		if locale == "" {
+			locale, _ = app.splitLocalePrefix(ctx.URL.Path)
+		}
+	}
+	if locale == "" && app.i18n.cookie != "" {
+		if c, err := ctx.Request.Cookie(app.i18n.cookie); err == nil {
+			locale = app.matchLocale(c.Value)
+		}
+	}
+	if locale == "" {
+		for _, l := range acceptLanguages(ctx.Request.Header.Get("Accept-Language")) {
+			if locale = app.matchLocale(l); locale != "" {
+				break
+			}
+		}
+	}
+	if locale == "" {
+		locale = app.i18n.defaultLocale
+	}
+	ctx.locale = locale
+	return locale
+}
+
+// SetLocale overrides the request's locale
+func (ctx *Context) SetLocale(locale string) *Context {
+	ctx.locale = locale
+	return ctx
+}
+
+// T translates message's key into the request's locale, see App.T
+func (ctx *Context) T(key string, args ...any) string {
+	return ctx.app.T(ctx.Locale(), key, args...)
+}
+
+// localePath prefixes the path with the request's locale,
+// when the locale prefix is enabled and the locale is not the default locale
+func (ctx *Context) localePath(p string) string {
+	if !ctx.app.i18n.prefix || !strings.HasPrefix(p, "/") {
+		return p
+	}
+	locale := ctx.Locale()
+	if locale == "" || locale == ctx.app.i18n.defaultLocale {
+		return p
+	}
+	if p == "/" {
+		return "/" + locale
+	}
+	return "/" + locale + p
+}
+
+func cloneI18n(x i18n) i18n {
+	rs := x
+	rs.fallback = make(map[string][]string, len(x.fallback))
+	for k, v := range x.fallback {
+		rs.fallback[k] = v
+	}
+	rs.catalogs = make(map[string]map[string]message, len(x.catalogs))
+	for k, v := range x.catalogs {
+		c := make(map[string]message, len(v))
+		for key, m := range v {
+			c[key] = m
+		}
+		rs.catalogs[k] = c
+	}
+	rs.plurals = make(map[string]PluralRule, len(x.plurals))
+	for k, v := range x.plurals {
+		rs.plurals[k] = v
+	}
+	return rs
+}
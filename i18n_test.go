@@ -0,0 +1,141 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestI18n(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		app.ParseConfigFile("testdata/i18n/config.yaml")
+		return app
+	}
+	newContext := func(app *App, target string, header http.Header) *Context {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		return NewAppContext(app, httptest.NewRecorder(), r)
+	}
+
+	t.Run("T", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, []string{"en", "th"}, app.Locales())
+		assert.Equal(t, "Hello, A", app.T("en", "hello", &Param{Name: "name", Value: "A"}))
+		assert.Equal(t, "สวัสดี A", app.T("th", "hello", map[string]any{"name": "A"}))
+		assert.Equal(t, "Your cart is empty", app.T("en", "cart.items", 0))
+		assert.Equal(t, "1 item", app.T("en", "cart.items", 1))
+		assert.Equal(t, "2 items", app.T("en", "cart.items", 2))
+		assert.Equal(t, "1 ชิ้น", app.T("th", "cart.items", 1))
+		assert.Equal(t, "หน้าแรก", app.T("th-TH", "nav.home"), "fallback")
+		assert.Equal(t, "English only", app.T("th", "only_en"), "default locale")
+		assert.Equal(t, "missing.key", app.T("th", "missing.key"))
+		assert.Equal(t, "Hello, {name}", app.T("en", "hello"))
+	})
+
+	t.Run("plural rules", func(t *testing.T) {
+		app := New()
+		app.Catalog("ru", map[string]any{
+			"files": map[string]any{"one": "{count} файл", "few": "{count} файла", "many": "{count} файлов"},
+		})
+		app.Catalog("fr", map[string]any{
+			"files": map[string]any{"one": "{count} fichier", "other": "{count} fichiers"},
+		})
+		assert.Equal(t, "1 файл", app.T("ru", "files", 1))
+		assert.Equal(t, "3 файла", app.T("ru", "files", 3))
+		assert.Equal(t, "11 файлов", app.T("ru", "files", 11))
+		assert.Equal(t, "0 fichier", app.T("fr", "files", 0))
+		assert.Equal(t, "2 fichiers", app.T("fr", "files", 2))
+
+		app.PluralRule("fr", func(n float64) string { return "other" })
+		assert.Equal(t, "0 fichiers", app.T("fr", "files", 0))
+	})
+
+	t.Run("Locale", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "en", newContext(app, "/", nil).Locale())
+		assert.Equal(t, "th", newContext(app, "/th/about", nil).Locale())
+		assert.Equal(t, "th", newContext(app, "/", http.Header{"Cookie": {"lang=th-TH"}}).Locale())
+		assert.Equal(t, "th", newContext(app, "/", http.Header{"Accept-Language": {"fr;q=0.9, th-TH;q=0.95, en;q=0.5"}}).Locale())
+		assert.Equal(t, "en", newContext(app, "/", http.Header{"Accept-Language": {"th;q=0, fr"}}).Locale())
+		assert.Equal(t, "en", newContext(app, "/en/about", http.Header{"Cookie": {"lang=th"}}).Locale(), "prefix takes precedence")
+		assert.Equal(t, "th", newContext(app, "/", nil).SetLocale("th").Locale())
+	})
+
+	t.Run("Route", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "/about", newContext(app, "/", nil).Route("about"))
+		assert.Equal(t, "/th/about", newContext(app, "/th/", nil).Route("about"))
+		assert.Equal(t, "/th", newContext(app, "/th/", nil).Route("home"))
+		assert.Equal(t, "/about", app.Route("about"))
+	})
+
+	t.Run("view", func(t *testing.T) {
+		app := newApp()
+		var path string
+		app.Handler(Handler(func(ctx *Context) error {
+			path = ctx.URL.Path
+			return ctx.View("page", map[string]any{"Name": "A", "Count": 2})
+		}))
+
+		w := invokeHandler(app, http.MethodGet, "/th/about", nil)
+		assert.Equal(t, "/about", path, "locale prefix is stripped")
+		assert.Equal(t, `<a href="/th/about">หน้าแรก</a> สวัสดี A 2 ชิ้น th`, strings.TrimSpace(w.Body.String()))
+
+		w = invokeHandler(app, http.MethodGet, "/about", nil)
+		assert.Equal(t, `<a href="/about">Home</a> Hello, A 2 items en`, strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("without context", func(t *testing.T) {
+		app := newApp()
+		app.Template().Parse("r", `{{route "about"}}`)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "r", nil))
+		assert.Equal(t, "/about", b.String())
+
+		// default locale
+		b.Reset()
+		assert.NoError(t, app.RenderView(&b, "page", map[string]any{"Name": "A", "Count": 2}))
+		assert.Equal(t, `<a href="/about">Home</a> Hello, A 2 items en`, strings.TrimSpace(b.String()))
+
+		app.Template().ParseText("mail", `{{t "hello" (param "name" .)}} ({{locale}})`)
+		b.Reset()
+		assert.NoError(t, app.RenderText(&b, "mail", "B"))
+		assert.Equal(t, "Hello, B (en)", b.String())
+	})
+
+	t.Run("app's t and locale", func(t *testing.T) {
+		app := New()
+		app.TemplateFunc("t", func(key string) string { return "my " + key })
+		app.TemplateFunc("locale", func() string { return "xx" })
+		app.Routes(Routes{"about": "/about"})
+		tp := app.Template()
+		tp.Parse("page", `{{t "hello"}} {{locale}} {{isRoute "about"}}`)
+		tp.Parse("mail", `{{t "hello"}} {{locale}}`)
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			assert.NoError(t, NewAppContext(app, w, httptest.NewRequest(http.MethodGet, "/", nil)).View("page", nil))
+			assert.Equal(t, "my hello xx false", w.Body.String())
+		}
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "mail", nil))
+		assert.Equal(t, "my hello xx", b.String())
+	})
+}
+
+func TestAcceptLanguages(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"th", "en-US", "en"}, acceptLanguages("en;q=0.5, th, en-US;q=0.8, *;q=0.1"))
+	assert.Empty(t, acceptLanguages(""))
+}
@@ -0,0 +1,296 @@
+package hime
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"text/template/parse"
+	"time"
+)
+
+// layout is a template set which wraps views at render time
+type layout struct {
+	name    string
+	root    string // template executed by the layout
+	parse   func(t *template.Template) *template.Template
+	trees   []*parse.Tree // layout's templates parsed when load
+	sources templateSources
+}
+
+// layoutTmpl is a view combined with a layout
+type layoutTmpl struct {
+	t       *tmpl
+	modTime time.Time
+}
+
+// Layout loads layout from files, to render views with Context.Layout.
+//
+// The layout executes the root template (see Root),
+// or the first file when there is no root.
+func (tp *Template) Layout(name string, filenames ...string) {
+	files := joinTemplateDir(tp.dir, filenames...)
+	fsys := tp.fs
+	sources := make(templateSources)
+	sources.addFiles(fsys, files...)
+
+	root := tp.root
+	if root == "" {
+		root = path.Base(filenames[0])
+	}
+
+	tp.newLayout(name, root, sources, func(t *template.Template) *template.Template {
+		if fsys == nil {
+			return template.Must(t.ParseFiles(files...))
+		}
+		return template.Must(t.ParseFS(fsys, files...))
+	})
+}
+
+// ParseLayout parses layout from text, see Layout.
+//
+// The layout executes the root template, or the text when there is no root.
+func (tp *Template) ParseLayout(name string, text string) {
+	sources := templateSources{name: {text: text}}
+
+	root := tp.root
+	if root == "" {
+		root = name
+	}
+
+	tp.newLayout(name, root, sources, func(t *template.Template) *template.Template {
+		return template.Must(t.New(name).Parse(text))
+	})
+}
+
+// Content sets the template executed when render views without layout,
+// see Context.NoLayout.
+//
+// Default is the view's first file, or the view's name for Parse
+func (tp *Template) Content(name string) {
+	tp.content = name
+}
+
+func (tp *Template) newLayout(name string, root string, sources templateSources, parser func(t *template.Template) *template.Template) {
+	if _, ok := tp.app.layouts[name]; ok {
+		panicf("layout '%s' already exists", name)
+	}
+
+	// parse once to report errors when load
+	t := parser(template.Must(tp.parent.Clone()))
+	if t.Lookup(root) == nil {
+		panicf("no root layout in layout '%s'", name)
+	}
+
+	var trees []*parse.Tree
+	for _, x := range t.Templates() {
+		if x.Tree == nil {
+			continue
+		}
+		if _, ok := sources[x.Tree.ParseName]; ok {
+			trees = append(trees, x.Tree.Copy())
+		}
+	}
+
+	tp.app.layouts[name] = &layout{
+		name:    name,
+		root:    root,
+		parse:   parser,
+		trees:   trees,
+		sources: sources,
+	}
+}
+
+// build adds the layout's templates into t,
+// the templates are parsed from sources only when reload
+func (l *layout) build(t *template.Template, reload bool) *template.Template {
+	if reload {
+		return l.parse(t)
+	}
+	for _, x := range l.trees {
+		template.Must(t.AddParseTree(x.Name, x.Copy()))
+	}
+	return t
+}
+
+// Layout renders views with the layout instead of the view's own root.
+//
+// The layout is combined with templates defined in the file of
+// the view's content template (see Template.Content), which replace
+// the layout's templates with the same name, e.g. {{block "title"}}
+func (ctx *Context) Layout(name string) *Context {
+	l, ok := ctx.app.layouts[name]
+	if !ok {
+		panic(newErrLayoutNotFound(name))
+	}
+	ctx.layout = l
+	ctx.noLayout = false
+	return ctx
+}
+
+// NoLayout renders views without layout,
+// executing the view's content template (see Template.Content)
+func (ctx *Context) NoLayout() *Context {
+	ctx.layout = nil
+	ctx.noLayout = true
+	return ctx
+}
+
+// viewTmpl returns the view combined with the context's layout
+func (ctx *Context) viewTmpl(t *tmpl) (*tmpl, error) {
+	switch {
+	case ctx.noLayout:
+		return t.withoutLayout()
+	case ctx.layout != nil:
+		return t.withLayout(ctx.layout, ctx.app.TemplateReload)
+	}
+	return t, nil
+}
+
+const noLayoutKey = "\x00"
+
+func (t *tmpl) withoutLayout() (*tmpl, error) {
+	c := t.c.Load()
+	if x, ok := c.layouts.Load(noLayoutKey); ok {
+		return x.(*layoutTmpl).t, nil
+	}
+
+	x := c.t.Lookup(t.content)
+	if x == nil || x.Tree == nil {
+		return nil, &ErrFragmentNotFound{View: t.name, Name: t.content}
+	}
+
+	nc := &compiledTmpl{t: x, m: c.m, minified: c.minified}
+	if c.pool != nil {
+		nc.pool = c.pool.lookup(t.content)
+	}
+	lt := t.derive(nc)
+	c.layouts.Store(noLayoutKey, &layoutTmpl{t: lt})
+	return lt, nil
+}
+
+// withLayout returns the view combined with the layout,
+// the combination is compiled once then cached until the view or the layout is reloaded
+func (t *tmpl) withLayout(l *layout, reload bool) (_ *tmpl, err error) {
+	if t.compose == nil {
+		return nil, fmt.Errorf("hime: template '%s' can not be used with layout", t.name)
+	}
+
+	c := t.c.Load()
+	var modTime time.Time
+	if reload {
+		modTime = l.sources.modTime()
+	}
+	if x, ok := c.layouts.Load(l.name); ok {
+		lt := x.(*layoutTmpl)
+		if !modTime.After(lt.modTime) {
+			return lt.t, nil
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = fmt.Errorf("hime: template '%s' with layout '%s'; %w", t.name, l.name, e)
+				return
+			}
+			err = fmt.Errorf("hime: template '%s' with layout '%s'; %v", t.name, l.name, r)
+		}
+	}()
+
+	// the layout is combined with templates defined in the view's content file only,
+	// so the view's own layout files do not replace the layout's templates,
+	// layout's root is added under an internal name so the content can not replace it
+	content := c.content
+	if content == nil {
+		return nil, &ErrFragmentNotFound{View: t.name, Name: t.content}
+	}
+	rootName := "hime:layout:" + l.name
+	nc := t.compose(func(newParent func() *template.Template) *template.Template {
+		parent := newParent().Funcs(template.FuncMap{
+			"templateName": func() string { return t.name },
+		})
+		root := l.build(parent, reload).Lookup(l.root)
+		if root == nil {
+			panicf("no root layout")
+		}
+		template.Must(parent.AddParseTree(rootName, root.Tree.Copy()))
+		content.addTo(parent, t.content)
+		return parent.Lookup(rootName)
+	})
+
+	lt := t.derive(nc)
+	lt.sources = mergeTemplateSources(t.sources, l.sources)
+	c.layouts.Store(l.name, &layoutTmpl{t: lt, modTime: modTime})
+	return lt, nil
+}
+
+// layoutContent is the view's content file captured when the view is built,
+// to combine with layouts at render time
+type layoutContent struct {
+	file     string
+	text     string
+	hasText  bool
+	markdown *MarkdownConfig
+	trees    []*parse.Tree // content file's trees, when the file has no source
+}
+
+// captureLayoutContent captures the file of the view's content template,
+// the file is parsed again when combined with layout,
+// since the view's other files may replace its templates in the view
+func captureLayoutContent(view *template.Template, name string, sources templateSources) *layoutContent {
+	content := view.Lookup(name)
+	if content == nil || content.Tree == nil {
+		return nil
+	}
+
+	file := content.Tree.ParseName
+	lc := &layoutContent{file: file}
+	if src, ok := sources[file]; ok {
+		text, err := src.read()
+		if err != nil {
+			panic(err)
+		}
+		lc.text, lc.hasText, lc.markdown = text, true, src.markdown
+		return lc
+	}
+	for _, x := range view.Templates() {
+		if x.Tree != nil && x.Tree.ParseName == file {
+			lc.trees = append(lc.trees, x.Tree.Copy())
+		}
+	}
+	return lc
+}
+
+// addTo adds the content file's templates to t
+func (lc *layoutContent) addTo(t *template.Template, content string) {
+	switch {
+	case lc.markdown != nil:
+		template.Must(parseMarkdown(t, []string{lc.file, content}, lc.text, *lc.markdown))
+	case lc.hasText:
+		template.Must(t.New(lc.file).Parse(lc.text))
+	default:
+		for _, x := range lc.trees {
+			template.Must(t.AddParseTree(x.Name, x.Copy()))
+		}
+	}
+}
+
+// derive returns a template which executes c as t
+func (t *tmpl) derive(c *compiledTmpl) *tmpl {
+	x := &tmpl{
+		name:      t.name,
+		component: t.component,
+		sources:   t.sources,
+		content:   t.content,
+	}
+	x.c.Store(c)
+	return x
+}
+
+func cloneLayouts(xs map[string]*layout) map[string]*layout {
+	rs := make(map[string]*layout, len(xs))
+	for k, v := range xs {
+		rs[k] = v
+	}
+	return rs
+}
@@ -0,0 +1,205 @@
+package hime
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		tp := app.Template()
+		tp.Dir("testdata/layout")
+		tp.Root("layout")
+		tp.Content("body")
+		tp.Layout("admin", "admin.tmpl")
+		tp.ParseLayout("print", `{{define "layout"}}<pre>{{template "body" .}}</pre>{{end}}`)
+		tp.ParseFiles("users", "users.tmpl", "_layout.tmpl")
+		tp.ParseFiles("about", "about.tmpl", "_layout.tmpl")
+		return app
+	}
+	render := func(app *App, f func(ctx *Context) error) (string, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		err := f(NewAppContext(app, w, r))
+		return w.Body.String(), err
+	}
+
+	t.Run("view's root", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "<main><h1>site</h1>users </main>", renderView(app, "users"))
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		app := newApp()
+		body, err := render(app, func(ctx *Context) error {
+			return ctx.Layout("admin").View("users", "x")
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "<admin><h1>admin</h1>users x</admin>", body)
+
+		body, err = render(app, func(ctx *Context) error {
+			return ctx.Layout("print").View("users", "x")
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "<pre>users x</pre>", body)
+
+		// view without layout is not changed
+		assert.Equal(t, "<main><h1>site</h1>users </main>", renderView(app, "users"))
+	})
+
+	t.Run("view fills layout's blocks", func(t *testing.T) {
+		app := newApp()
+		body, err := render(app, func(ctx *Context) error {
+			return ctx.Layout("admin").View("about", nil)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "<admin><h1>About</h1>about</admin>", body)
+	})
+
+	t.Run("no layout", func(t *testing.T) {
+		app := newApp()
+		body, err := render(app, func(ctx *Context) error {
+			return ctx.NoLayout().View("users", "x")
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "users x", body)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		app := newApp()
+		v := app.template["users"]
+
+		a, err := v.withLayout(app.layouts["admin"], false)
+		assert.NoError(t, err)
+		b, err := v.withLayout(app.layouts["admin"], false)
+		assert.NoError(t, err)
+		assert.Same(t, a, b)
+
+		a, err = v.withoutLayout()
+		assert.NoError(t, err)
+		b, err = v.withoutLayout()
+		assert.NoError(t, err)
+		assert.Same(t, a, b)
+	})
+
+	t.Run("layout not found", func(t *testing.T) {
+		app := newApp()
+		assert.PanicsWithError(t, "hime: layout 'notfound' not found", func() {
+			render(app, func(ctx *Context) error {
+				return ctx.Layout("notfound").View("users", nil)
+			})
+		})
+	})
+
+	t.Run("content not found", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.Content("missing")
+		tp.Parse("index", `{{define "main"}}x{{end}}`)
+		tp.ParseLayout("l", `<main>{{template "main" .}}</main>`)
+
+		_, err := render(app, func(ctx *Context) error {
+			return ctx.NoLayout().View("index", nil)
+		})
+		var notFound *ErrFragmentNotFound
+		assert.True(t, errors.As(err, &notFound))
+
+		_, err = render(app, func(ctx *Context) error {
+			return ctx.Layout("l").View("index", nil)
+		})
+		assert.True(t, errors.As(err, &notFound))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		app := newApp()
+		assert.Panics(t, func() {
+			app.Template().ParseLayout("print", `x`)
+		})
+	})
+
+	t.Run("config", func(t *testing.T) {
+		app := New()
+		app.ParseConfig([]byte(`
+templates:
+- dir: testdata/layout
+  root: layout
+  content: body
+  layouts:
+    admin: [admin.tmpl]
+  list:
+    users: [users.tmpl, _layout.tmpl]
+`))
+		body, err := render(app, func(ctx *Context) error {
+			return ctx.Layout("admin").View("users", "x")
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "<admin><h1>admin</h1>users x</admin>", body)
+
+		body, err = render(app, func(ctx *Context) error {
+			return ctx.NoLayout().View("users", "x")
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "users x", body)
+	})
+
+	t.Run("without reload", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFile(t, filepath.Join(dir, "admin.tmpl"), `{{define "layout"}}<a1>{{template "body" .}}</a1>{{end}}`, time.Now())
+		writeTemplateFile(t, filepath.Join(dir, "users.tmpl"), `{{define "body"}}users{{end}}`, time.Now())
+
+		app := New()
+		tp := app.Template()
+		tp.Dir(dir)
+		tp.Root("layout")
+		tp.Layout("admin", "admin.tmpl")
+		tp.ParseFiles("users", "users.tmpl", "admin.tmpl")
+
+		// files are changed after load, render uses templates parsed when load
+		writeTemplateFile(t, filepath.Join(dir, "admin.tmpl"), `{{define "layout"}}<a2>{{template "body" .}}</a2>{{end}}`, time.Now().Add(time.Second))
+		writeTemplateFile(t, filepath.Join(dir, "users.tmpl"), `{{define "body"}}users2{{end}}`, time.Now().Add(time.Second))
+
+		body, err := render(app, func(ctx *Context) error {
+			return ctx.Layout("admin").View("users", nil)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "<a1>users</a1>", body)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFile(t, filepath.Join(dir, "admin.tmpl"), `{{define "layout"}}<a1>{{template "body" .}}</a1>{{end}}`, time.Now())
+		writeTemplateFile(t, filepath.Join(dir, "users.tmpl"), `{{define "body"}}users{{end}}`, time.Now())
+
+		app := New()
+		app.TemplateReload = true
+		tp := app.Template()
+		tp.Dir(dir)
+		tp.Root("layout")
+		tp.Layout("admin", "admin.tmpl")
+		tp.ParseFiles("users", "users.tmpl", "admin.tmpl")
+
+		view := func() string {
+			body, err := render(app, func(ctx *Context) error {
+				return ctx.Layout("admin").View("users", nil)
+			})
+			assert.NoError(t, err)
+			return body
+		}
+		assert.Equal(t, "<a1>users</a1>", view())
+
+		writeTemplateFile(t, filepath.Join(dir, "admin.tmpl"), `{{define "layout"}}<a2>{{template "body" .}}</a2>{{end}}`, time.Now().Add(time.Second))
+		assert.Equal(t, "<a2>users</a2>", view())
+
+		writeTemplateFile(t, filepath.Join(dir, "users.tmpl"), `{{define "body"}}users2{{end}}`, time.Now().Add(2*time.Second))
+		assert.Equal(t, "<a2>users2</a2>", view())
+	})
+}
@@ -0,0 +1,162 @@
+package hime
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LiveReloadPath is the path of the live reload event stream
+const LiveReloadPath = "/.hime/livereload"
+
+const (
+	liveReloadInterval  = 500 * time.Millisecond
+	liveReloadKeepAlive = 15 * time.Second
+)
+
+var liveReloadScript = []byte(`<script>(function(){var es=new EventSource("` + LiveReloadPath + `");es.addEventListener("reload",function(){es.close();location.reload()})})()</script>`)
+
+// liveReload tracks changes of templates and watched directories
+type liveReload struct {
+	mu        sync.Mutex
+	dirs      []string
+	dirsSig   dirSignature
+	checkedAt time.Time
+	version   uint64
+}
+
+type dirSignature struct {
+	modTime time.Time
+	files   int
+}
+
+// LiveReloadWatch adds static directories to watch for live reload
+func (app *App) LiveReloadWatch(dirs ...string) {
+	app.liveReload.mu.Lock()
+	defer app.liveReload.mu.Unlock()
+
+	app.liveReload.dirs = append(app.liveReload.dirs, dirs...)
+	app.liveReload.dirsSig = scanDirs(app.liveReload.dirs)
+}
+
+func (app *App) liveReloadEnabled() bool {
+	return app.Dev && app.LiveReload
+}
+
+// liveReloadVersion checks for changes at most once per interval,
+// and returns the version which is increased on every change
+func (app *App) liveReloadVersion() uint64 {
+	lr := &app.liveReload
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if time.Since(lr.checkedAt) < liveReloadInterval {
+		return lr.version
+	}
+	lr.checkedAt = time.Now()
+
+	changed := app.reloadTemplates()
+	if len(lr.dirs) > 0 {
+		sig := scanDirs(lr.dirs)
+		if sig != lr.dirsSig {
+			lr.dirsSig = sig
+			changed = true
+		}
+	}
+	if changed {
+		lr.version++
+	}
+	return lr.version
+}
+
+// scanDirs returns the signature of files in dirs,
+// which changes when a file is added, removed or modified
+func scanDirs(dirs []string) dirSignature {
+	var sig dirSignature
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			sig.files++
+			if m := fi.ModTime(); m.After(sig.modTime) {
+				sig.modTime = m
+			}
+			return nil
+		})
+	}
+	return sig
+}
+
+// serveLiveReload serves server-sent events,
+// sending a reload event when templates or watched directories change
+func (app *App) serveLiveReload(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	version := app.liveReloadVersion()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(": connected\n\n"))
+	if rc.Flush() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(liveReloadInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		var msg string
+		if v := app.liveReloadVersion(); v != version {
+			version = v
+			msg = "event: reload\ndata: reload\n\n"
+		} else if time.Since(lastWrite) >= liveReloadKeepAlive {
+			msg = ": keep-alive\n\n"
+		} else {
+			continue
+		}
+
+		if _, err := w.Write([]byte(msg)); err != nil {
+			return
+		}
+		if rc.Flush() != nil {
+			return
+		}
+		lastWrite = time.Now()
+	}
+}
+
+// injectLiveReload inserts the live reload script before the last </body>,
+// or appends it to documents without </body> (e.g. minified documents),
+// the script gets the nonce attribute when the nonce is not empty
+func injectLiveReload(buf *bytes.Buffer, nonce string) {
+	b := buf.Bytes()
+	i := bytes.LastIndex(b, []byte("</body>"))
+	if i < 0 {
+		i = len(b)
+	}
+
+	tail := append([]byte(nil), b[i:]...)
+	buf.Truncate(i)
+	if nonce != "" {
+		buf.WriteString(`<script nonce="` + nonce + `"`)
+		buf.Write(bytes.TrimPrefix(liveReloadScript, []byte("<script")))
+	} else {
+		buf.Write(liveReloadScript)
+	}
+	buf.Write(tail)
+}
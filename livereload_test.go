@@ -0,0 +1,155 @@
+package hime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveReloadInject(t *testing.T) {
+	t.Parallel()
+
+	newApp := func(dev, liveReload bool) *App {
+		app := New()
+		app.Dev = dev
+		app.LiveReload = liveReload
+		tp := app.Template()
+		tp.Parse("page", "<html><body><h1>{{.}}</h1></body></html>")
+		tp.Parse("fragment", "<h1>{{.}}</h1>")
+		tp.ParseComponent("c", "<body>c</body>")
+		return app
+	}
+	render := func(app *App, f func(ctx *Context) error) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, f(NewAppContext(app, w, r)))
+		return w.Body.String()
+	}
+	renderHTMX := func(app *App, f func(ctx *Context) error) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("HX-Request", "true")
+		assert.NoError(t, f(NewAppContext(app, w, r)))
+		return w.Body.String()
+	}
+
+	app := newApp(true, true)
+	assert.Equal(t, "<html><body><h1>x</h1>"+string(liveReloadScript)+"</body></html>", render(app, func(ctx *Context) error {
+		return ctx.View("page", "x")
+	}))
+	assert.Equal(t, "<h1>x</h1>"+string(liveReloadScript), render(app, func(ctx *Context) error {
+		return ctx.View("fragment", "x")
+	}))
+	assert.Equal(t, "<h1>x</h1>", renderHTMX(app, func(ctx *Context) error {
+		return ctx.View("fragment", "x")
+	}))
+	assert.Equal(t, "<body>c"+string(liveReloadScript)+"</body>", render(app, func(ctx *Context) error {
+		return ctx.Component("c", nil)
+	}))
+	assert.Equal(t, "<body>x"+string(liveReloadScript)+"</body>", render(app, func(ctx *Context) error {
+		return ctx.Render("<body>{{.}}</body>", "x")
+	}))
+
+	// minifier removes </body>
+	app = newApp(true, true)
+	tp := app.Template()
+	tp.Minify()
+	tp.Parse("minified", "<html><body><p>hi</p></body></html>")
+	assert.Equal(t, "<p>hi"+string(liveReloadScript), render(app, func(ctx *Context) error {
+		return ctx.View("minified", nil)
+	}))
+
+	// requires dev mode
+	for _, app := range []*App{newApp(false, true), newApp(true, false)} {
+		assert.Equal(t, "<html><body><h1>x</h1></body></html>", render(app, func(ctx *Context) error {
+			return ctx.View("page", "x")
+		}))
+	}
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBufferString("<body><p>a</p></body><!-- </body> --></html>")
+	injectLiveReload(buf, "")
+	assert.Equal(t, "<body><p>a</p></body><!-- "+string(liveReloadScript)+"</body> --></html>", buf.String())
+
+	buf = bytes.NewBufferString("<p>a")
+	injectLiveReload(buf, "n1")
+	assert.Equal(t, `<p>a<script nonce="n1">`+strings.TrimPrefix(string(liveReloadScript), "<script>"), buf.String())
+}
+
+func TestLiveReloadEndpoint(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	static := filepath.Join(dir, "static")
+	assert.NoError(t, os.Mkdir(static, 0755))
+	writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), "v1", time.Now())
+	writeTemplateFile(t, filepath.Join(static, "app.css"), "body{}", time.Now())
+
+	app := New()
+	app.Dev = true
+	app.LiveReload = true
+	app.LiveReloadWatch(static)
+	tp := app.Template()
+	tp.Dir(dir)
+	tp.ParseFiles("index", "index.tmpl")
+
+	ts := httptest.NewServer(app)
+	defer ts.Close()
+
+	// waitReload connects to the event stream, runs change,
+	// then waits for the reload event
+	waitReload := func(change func()) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+LiveReloadPath, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return false
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		s := bufio.NewScanner(resp.Body)
+		s.Scan() // connected
+		change()
+		for s.Scan() {
+			if strings.HasPrefix(s.Text(), "event: reload") {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.True(t, waitReload(func() {
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), "v2", time.Now().Add(time.Second))
+	}))
+	assert.True(t, waitReload(func() {
+		writeTemplateFile(t, filepath.Join(static, "app.js"), "", time.Now())
+	}))
+
+	w := httptest.NewRecorder()
+	NewAppContext(app, w, httptest.NewRequest(http.MethodGet, "/", nil)).View("index", nil)
+	assert.Equal(t, "v2"+string(liveReloadScript), w.Body.String())
+}
+
+func TestLiveReloadEndpointDisabled(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.LiveReload = true
+	w := invokeHandler(app, http.MethodGet, LiveReloadPath, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,507 @@
+package hime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	htmllex "github.com/tdewolff/parse/v2/html"
+)
+
+// mailSubject is the name of the template which renders mail's subject
+const mailSubject = "subject"
+
+// Mail is a rendered email message, see App.RenderMail
+type Mail struct {
+	// Header is additional header, e.g. From and To
+	Header  textproto.MIMEHeader
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// RenderMail renders the mail from the text template and the view with the same name,
+// either can be missing.
+//
+// Subject is rendered from the "subject" template ({{define "subject"}})
+// of the text template, or the view.
+// CSS rules from <style> elements of the view are inlined into style attributes,
+// when the selector is a type, class or id selector, or a combination of them.
+func (app *App) RenderMail(name string, data any) (*Mail, error) {
+	return app.RenderMailContext(context.Background(), name, data)
+}
+
+// RenderMailContext renders the mail, see RenderMail.
+//
+// Rendering stops with ctx's error when ctx is done.
+func (app *App) RenderMailContext(ctx context.Context, name string, data any) (*Mail, error) {
+	text, hasText := app.text[name]
+	view, hasView := app.template[name]
+	if !hasText && !hasView {
+		panic(newErrTemplateNotFound(name))
+	}
+
+	var m Mail
+	buf := getBytes()
+	defer putBytes(buf)
+
+	if hasText {
+		if err := renderText(ctx, buf, text, data); err != nil {
+			return nil, err
+		}
+		m.Text = buf.String()
+
+		if x := text.Lookup(mailSubject); x != nil {
+			buf.Reset()
+			if err := renderText(ctx, buf, x, data); err != nil {
+				return nil, err
+			}
+			m.Subject = strings.TrimSpace(buf.String())
+		}
+	}
+
+	if hasView {
+		buf.Reset()
+		if err := app.RenderViewContext(ctx, buf, name, data); err != nil {
+			return nil, err
+		}
+		m.HTML = inlineCSS(buf.String())
+
+		if m.Subject == "" && view.load().Lookup(mailSubject) != nil {
+			buf.Reset()
+			if err := view.ExecuteFragment(nil, buf, mailSubject, data); err != nil {
+				return nil, err
+			}
+			m.Subject = strings.TrimSpace(html.UnescapeString(buf.String()))
+		}
+	}
+
+	return &m, nil
+}
+
+// Bytes returns the MIME message, it is empty when WriteTo fails
+func (m *Mail) Bytes() []byte {
+	var b bytes.Buffer
+	m.WriteTo(&b)
+	return b.Bytes()
+}
+
+// WriteTo writes the MIME message into w,
+// the body is multipart/alternative when the mail has both text and html.
+//
+// Address headers (From, To, Cc, Bcc and Reply-To) must be address lists,
+// their display names are encoded per address.
+func (m *Mail) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+
+	keys := make([]string, 0, len(m.Header))
+	for k := range m.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range m.Header[k] {
+			v, err := encodeMailHeader(k, v)
+			if err != nil {
+				return 0, err
+			}
+			fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+		}
+	}
+	if m.Subject != "" {
+		fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		mw := multipart.NewWriter(&b)
+		fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+		for _, p := range []struct{ contentType, body string }{
+			{"text/plain; charset=utf-8", m.Text},
+			{"text/html; charset=utf-8", m.HTML},
+		} {
+			pw, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {p.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return 0, err
+			}
+			writeQuotedPrintable(pw, p.body)
+		}
+		mw.Close()
+	case m.HTML != "":
+		writeMailPart(&b, "text/html; charset=utf-8", m.HTML)
+	default:
+		writeMailPart(&b, "text/plain; charset=utf-8", m.Text)
+	}
+
+	return b.WriteTo(w)
+}
+
+// mailAddressHeaders are headers which contain address lists
+var mailAddressHeaders = map[string]bool{
+	"From":     true,
+	"To":       true,
+	"Cc":       true,
+	"Bcc":      true,
+	"Reply-To": true,
+}
+
+// encodeMailHeader encodes the header's value,
+// address lists are encoded per address so they can be parsed
+func encodeMailHeader(k, v string) (string, error) {
+	if !mailAddressHeaders[textproto.CanonicalMIMEHeaderKey(k)] {
+		return mime.QEncoding.Encode("utf-8", v), nil
+	}
+
+	addrs, err := mail.ParseAddressList(v)
+	if err != nil {
+		return "", fmt.Errorf("hime: mail header '%s'; %w", k, err)
+	}
+	xs := make([]string, len(addrs))
+	for i, a := range addrs {
+		if a.Name == "" {
+			xs[i] = a.Address
+			continue
+		}
+		xs[i] = a.String()
+	}
+	return strings.Join(xs, ", "), nil
+}
+
+func writeMailPart(b *bytes.Buffer, contentType string, body string) {
+	fmt.Fprintf(b, "Content-Type: %s\r\n", contentType)
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	writeQuotedPrintable(b, body)
+}
+
+func writeQuotedPrintable(w io.Writer, s string) {
+	qw := quotedprintable.NewWriter(w)
+	io.WriteString(qw, s)
+	qw.Close()
+}
+
+// cssRule is a style rule with a simple selector
+type cssRule struct {
+	tag         string // empty or "*" matches all
+	id          string
+	classes     []string
+	specificity int
+	decls       []cssDecl
+}
+
+type cssDecl struct {
+	prop  string
+	value string
+}
+
+func (r *cssRule) match(tag, id string, classes []string) bool {
+	if r.tag != "" && r.tag != "*" && r.tag != tag {
+		return false
+	}
+	if r.id != "" && r.id != id {
+		return false
+	}
+	for _, c := range r.classes {
+		found := false
+		for _, x := range classes {
+			if x == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCSSRules parses rules with simple selectors from a stylesheet,
+// other rules and at-rules are skipped
+func parseCSSRules(s string) []*cssRule {
+	s = stripCSSComments(s)
+
+	var rs []*cssRule
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return rs
+		}
+
+		if s[0] == '@' {
+			s = skipCSSAtRule(s)
+			continue
+		}
+
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			return rs
+		}
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 {
+			return rs
+		}
+		selectors, body := s[:open], s[open+1:open+end]
+		s = s[open+end+1:]
+
+		decls := parseCSSDecls(body)
+		if len(decls) == 0 {
+			continue
+		}
+		for _, sel := range strings.Split(selectors, ",") {
+			r := parseCSSSelector(strings.TrimSpace(sel))
+			if r == nil {
+				continue
+			}
+			r.decls = decls
+			rs = append(rs, r)
+		}
+	}
+}
+
+func stripCSSComments(s string) string {
+	for {
+		i := strings.Index(s, "/*")
+		if i < 0 {
+			return s
+		}
+		j := strings.Index(s[i+2:], "*/")
+		if j < 0 {
+			return s[:i]
+		}
+		s = s[:i] + s[i+2+j+2:]
+	}
+}
+
+// skipCSSAtRule returns s after the at-rule at the beginning of s
+func skipCSSAtRule(s string) string {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case ';':
+			if depth == 0 {
+				return s[i+1:]
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth <= 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return ""
+}
+
+func parseCSSDecls(s string) []cssDecl {
+	var rs []cssDecl
+	for _, d := range strings.Split(s, ";") {
+		prop, value, ok := strings.Cut(d, ":")
+		prop, value = strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(value)
+		if !ok || prop == "" || value == "" {
+			continue
+		}
+		rs = append(rs, cssDecl{prop: prop, value: value})
+	}
+	return rs
+}
+
+// parseCSSSelector parses type, class and id selectors, e.g. "p.note#intro",
+// returns nil for other selectors
+func parseCSSSelector(s string) *cssRule {
+	if s == "" {
+		return nil
+	}
+
+	var r cssRule
+	i := 0
+	for i < len(s) && isCSSNameChar(s[i]) || i == 0 && s[0] == '*' {
+		i++
+	}
+	r.tag = strings.ToLower(s[:i])
+	if r.tag != "" && r.tag != "*" {
+		r.specificity++
+	}
+
+	for i < len(s) {
+		kind := s[i]
+		if kind != '.' && kind != '#' {
+			return nil
+		}
+		i++
+		start := i
+		for i < len(s) && isCSSNameChar(s[i]) {
+			i++
+		}
+		if start == i {
+			return nil
+		}
+		if kind == '.' {
+			r.classes = append(r.classes, s[start:i])
+			r.specificity += 10
+		} else {
+			r.id = s[start:i]
+			r.specificity += 100
+		}
+	}
+	return &r
+}
+
+func isCSSNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_'
+}
+
+// noInlineCSSTags are elements which do not get inlined style
+var noInlineCSSTags = map[string]bool{
+	"html": true, "head": true, "title": true, "meta": true, "link": true,
+	"style": true, "script": true, "base": true,
+}
+
+// inlineCSS inlines rules from <style> elements into style attributes,
+// the element's own style takes precedence
+func inlineCSS(s string) string {
+	var rules []*cssRule
+	{
+		l := htmllex.NewLexer(parse.NewInputString(s))
+		inStyle := false
+		for {
+			tt, data := l.Next()
+			if tt == htmllex.ErrorToken {
+				break
+			}
+			switch tt {
+			case htmllex.StartTagToken:
+				inStyle = string(l.Text()) == "style"
+			case htmllex.TextToken:
+				if inStyle {
+					rules = append(rules, parseCSSRules(string(data))...)
+				}
+			case htmllex.EndTagToken:
+				inStyle = false
+			}
+		}
+	}
+	if len(rules) == 0 {
+		return s
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].specificity < rules[j].specificity
+	})
+
+	type attr struct {
+		key  string
+		val  string
+		data []byte
+	}
+
+	var b strings.Builder
+	l := htmllex.NewLexer(parse.NewInputString(s))
+	var (
+		tag   string
+		attrs []attr
+	)
+	for {
+		tt, data := l.Next()
+		switch tt {
+		case htmllex.ErrorToken:
+			if l.Err() != io.EOF {
+				return s
+			}
+			return b.String()
+		case htmllex.StartTagToken:
+			tag = string(l.Text())
+			attrs = attrs[:0]
+			b.Write(data)
+		case htmllex.AttributeToken:
+			attrs = append(attrs, attr{
+				key:  string(l.AttrKey()),
+				val:  unquoteAttr(string(l.AttrVal())),
+				data: data,
+			})
+		case htmllex.StartTagCloseToken, htmllex.StartTagVoidToken:
+			var id, style string
+			var classes []string
+			for _, a := range attrs {
+				switch a.key {
+				case "id":
+					id = a.val
+				case "class":
+					classes = strings.Fields(a.val)
+				case "style":
+					style = a.val
+				}
+			}
+
+			var decls []cssDecl
+			if !noInlineCSSTags[tag] {
+				for _, r := range rules {
+					if r.match(tag, id, classes) {
+						decls = append(decls, r.decls...)
+					}
+				}
+			}
+			if len(decls) == 0 {
+				for _, a := range attrs {
+					b.Write(a.data)
+				}
+				b.Write(data)
+				continue
+			}
+
+			for _, a := range attrs {
+				if a.key != "style" {
+					b.Write(a.data)
+				}
+			}
+			merged := joinCSSDecls(decls)
+			if style = strings.TrimSuffix(strings.TrimSpace(html.UnescapeString(style)), ";"); style != "" {
+				merged += ";" + style
+			}
+			b.WriteString(` style="` + html.EscapeString(merged) + `"`)
+			b.Write(data)
+		default:
+			b.Write(data)
+		}
+	}
+}
+
+// joinCSSDecls joins declarations, the last declaration of a property wins
+func joinCSSDecls(decls []cssDecl) string {
+	index := make(map[string]int)
+	var rs []cssDecl
+	for _, d := range decls {
+		if i, ok := index[d.prop]; ok {
+			rs[i].value = d.value
+			continue
+		}
+		index[d.prop] = len(rs)
+		rs = append(rs, d)
+	}
+
+	xs := make([]string, len(rs))
+	for i, d := range rs {
+		xs[i] = d.prop + ":" + d.value
+	}
+	return strings.Join(xs, ";")
+}
+
+func unquoteAttr(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
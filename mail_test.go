@@ -0,0 +1,160 @@
+package hime
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMail(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		app.Routes(Routes{"home": "/home"})
+		app.Globals(Globals{"site": "hime"})
+		app.Template().ParseConfigFile("testdata/mail/config.yaml")
+		return app
+	}
+
+	t.Run("text and html", func(t *testing.T) {
+		m, err := newApp().RenderMail("welcome", map[string]any{"Name": "A"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "Welcome, A!", m.Subject)
+		assert.Equal(t, "Hi A,\nVisit /home.\nSent by hime\n", m.Text)
+		assert.Contains(t, m.HTML, `<p style="color:#333;margin:0">Hi A,</p>`)
+		assert.Contains(t, m.HTML, `<a class="btn" href="/home" style="color:red;font-family:&#34;Arial&#34;;padding: 4px">Visit</a>`)
+		assert.Contains(t, m.HTML, `@media (max-width: 600px)`, "style element is kept")
+
+		m.Header = textproto.MIMEHeader{"To": {"a@example.com"}}
+		msg, err := mail.ReadMessage(strings.NewReader(string(m.Bytes())))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "a@example.com", msg.Header.Get("To"))
+		assert.Equal(t, "Welcome, A!", msg.Header.Get("Subject"))
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		r := multipart.NewReader(msg.Body, params["boundary"])
+		var parts []string
+		for {
+			p, err := r.NextRawPart()
+			if err != nil {
+				break
+			}
+			assert.Equal(t, "quoted-printable", p.Header.Get("Content-Transfer-Encoding"))
+			b, _ := io.ReadAll(quotedprintable.NewReader(p))
+			b = []byte(strings.ReplaceAll(string(b), "\r\n", "\n"))
+			parts = append(parts, p.Header.Get("Content-Type")+"|"+string(b))
+		}
+		if assert.Len(t, parts, 2) {
+			assert.Equal(t, "text/plain; charset=utf-8|"+m.Text, parts[0])
+			assert.Equal(t, "text/html; charset=utf-8|"+m.HTML, parts[1])
+		}
+	})
+
+	t.Run("html only", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("m", `{{define "subject"}}Tom &amp; Jerry{{end}}<p>hi</p>`)
+		m, err := app.RenderMail("m", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "Tom & Jerry", m.Subject)
+		assert.Empty(t, m.Text)
+
+		msg, err := mail.ReadMessage(strings.NewReader(string(m.Bytes())))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "text/html; charset=utf-8", msg.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("address headers", func(t *testing.T) {
+		m := &Mail{
+			Header: textproto.MIMEHeader{
+				"From":     {"สมชาย <a@example.com>"},
+				"To":       {"B <b@example.com>, ทดสอบ <c@example.com>"},
+				"Reply-To": {"d@example.com"},
+				"X-Note":   {"สวัสดี"},
+			},
+			Subject: "สวัสดี",
+			Text:    "hi",
+		}
+		msg, err := mail.ReadMessage(strings.NewReader(string(m.Bytes())))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		from, err := msg.Header.AddressList("From")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []*mail.Address{{Name: "สมชาย", Address: "a@example.com"}}, from)
+		}
+		to, err := msg.Header.AddressList("To")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []*mail.Address{
+				{Name: "B", Address: "b@example.com"},
+				{Name: "ทดสอบ", Address: "c@example.com"},
+			}, to)
+		}
+		replyTo, err := msg.Header.AddressList("Reply-To")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []*mail.Address{{Address: "d@example.com"}}, replyTo)
+		}
+
+		dec := new(mime.WordDecoder)
+		note, _ := dec.DecodeHeader(msg.Header.Get("X-Note"))
+		assert.Equal(t, "สวัสดี", note)
+		subject, _ := dec.DecodeHeader(msg.Header.Get("Subject"))
+		assert.Equal(t, "สวัสดี", subject)
+	})
+
+	t.Run("invalid address header", func(t *testing.T) {
+		m := &Mail{Header: textproto.MIMEHeader{"To": {"not an address"}}, Text: "hi"}
+		_, err := m.WriteTo(io.Discard)
+		assert.Error(t, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		assert.Panics(t, func() { New().RenderMail("m", nil) })
+	})
+}
+
+func TestInlineCSS(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in, out string
+	}{
+		{`<p>a</p>`, `<p>a</p>`},
+		{
+			`<style>p{color:red}</style><p>a</p>`,
+			`<style>p{color:red}</style><p style="color:red">a</p>`,
+		},
+		{
+			`<style>#x{color:blue} p.a{color:green} p{color:red;margin:0}</style><p id="x" class="a">a</p><p class=a>b</p>`,
+			`<style>#x{color:blue} p.a{color:green} p{color:red;margin:0}</style><p id="x" class="a" style="color:blue;margin:0">a</p><p class=a style="color:green;margin:0">b</p>`,
+		},
+		{
+			`<style>/* c */ div > p, p:hover, .b { color: red }</style><p class="b" style="color:blue">a</p><br class="b"/>`,
+			`<style>/* c */ div > p, p:hover, .b { color: red }</style><p class="b" style="color:red;color:blue">a</p><br class="b" style="color:red"/>`,
+		},
+		{
+			`<style>p{color:red}</style><p style='font-family:"a\"><x>";'>a</p>`,
+			`<style>p{color:red}</style><p style="color:red;font-family:&#34;a\&#34;&gt;&lt;x&gt;&#34;">a</p>`,
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.out, inlineCSS(c.in))
+	}
+}
@@ -0,0 +1,1303 @@
+package hime
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarkdownConfig is markdown views config
+//
+// Example:
+//
+//	markdown:
+//	  anchors: true
+type MarkdownConfig struct {
+	// Anchors adds id generated from the heading's text to headings
+	Anchors bool `yaml:"anchors" json:"anchors"`
+}
+
+// MarkdownHeading is a heading in markdown view, see template func toc
+type MarkdownHeading struct {
+	Level int
+	ID    string // empty when anchors is not enabled
+	Text  string
+}
+
+// markdownDoc is a markdown document converted to html
+type markdownDoc struct {
+	html     string
+	meta     map[string]any
+	headings []MarkdownHeading
+}
+
+// Markdown sets config for markdown views.
+//
+// Markdown files (.md, .markdown) in views, pages and components are converted to html when parse,
+// and defined as the file's name and the content template (see Content),
+// so layouts can execute them as {{template "body" .}}.
+// Template func {{frontMatter}} returns the file's yaml front matter,
+// and {{toc}} returns the file's headings.
+func (tp *Template) Markdown(cfg MarkdownConfig) {
+	tp.markdown = cfg
+}
+
+// ParseMarkdown parses view from markdown text, see Markdown
+func (tp *Template) ParseMarkdown(name string, text string) {
+	cfg := tp.markdown
+	content := tp.content
+	sources := templateSources{name: {text: text, markdown: &cfg}}
+	tp.newTemplate(name, name, sources, func(t *template.Template) *template.Template {
+		return template.Must(parseMarkdown(t, []string{name, content}, text, cfg))
+	})
+}
+
+// isMarkdownFile reports whether the file is markdown
+func isMarkdownFile(name string) bool {
+	switch path.Ext(name) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// parseTemplateFiles parses files into t,
+// markdown files are defined as the file's base name and names
+func parseTemplateFiles(t *template.Template, fsys fs.FS, files []string, cfg MarkdownConfig, names ...string) *template.Template {
+	var tmplFiles, mdFiles []string
+	for _, f := range files {
+		if isMarkdownFile(f) {
+			mdFiles = append(mdFiles, f)
+		} else {
+			tmplFiles = append(tmplFiles, f)
+		}
+	}
+
+	if len(tmplFiles) > 0 {
+		if fsys == nil {
+			t = template.Must(t.ParseFiles(tmplFiles...))
+		} else {
+			t = template.Must(t.ParseFS(fsys, tmplFiles...))
+		}
+	}
+	for _, f := range mdFiles {
+		text, err := templateSource{fs: fsys, path: f}.read()
+		if err != nil {
+			panic(err)
+		}
+		template.Must(parseMarkdown(t, append([]string{path.Base(f)}, names...), text, cfg))
+	}
+	return t
+}
+
+// parseMarkdown converts markdown text then adds the html into t as names,
+// the first name is the parse name, returns the template of the first name
+func parseMarkdown(t *template.Template, names []string, text string, cfg MarkdownConfig) (*template.Template, error) {
+	doc, err := convertMarkdownDoc(text, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("hime: parse markdown '%s'; %w", names[0], err)
+	}
+
+	t.Funcs(template.FuncMap{
+		"frontMatter": func() map[string]any { return doc.meta },
+		"toc":         func() []MarkdownHeading { return doc.headings },
+	})
+
+	var rs *template.Template
+	added := make(map[string]bool)
+	for _, name := range names {
+		if name == "" || added[name] {
+			continue
+		}
+		added[name] = true
+
+		tree := &parse.Tree{
+			Name:      name,
+			ParseName: names[0],
+			Root: &parse.ListNode{
+				NodeType: parse.NodeList,
+				Nodes: []parse.Node{
+					&parse.TextNode{NodeType: parse.NodeText, Text: []byte(doc.html)},
+				},
+			},
+		}
+		x, err := t.AddParseTree(name, tree)
+		if err != nil {
+			return nil, err
+		}
+		if rs == nil {
+			rs = x
+		}
+	}
+	return rs, nil
+}
+
+// markdown marks markdown files in sources to convert with cfg when reparse
+func (xs templateSources) markdown(cfg MarkdownConfig) {
+	for k, s := range xs {
+		if isMarkdownFile(s.path) {
+			s.markdown = &cfg
+			xs[k] = s
+		}
+	}
+}
+
+func tfMarkdown(text string) template.HTML {
+	p := newMarkdownParser(MarkdownConfig{})
+	return template.HTML(p.convert(text))
+}
+
+// convertMarkdownDoc converts markdown with front matter
+func convertMarkdownDoc(text string, cfg MarkdownConfig) (*markdownDoc, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var meta map[string]any
+	if fm, body, ok := splitFrontMatter(text); ok {
+		if err := yaml.Unmarshal([]byte(fm), &meta); err != nil {
+			return nil, err
+		}
+		text = body
+	}
+
+	p := newMarkdownParser(cfg)
+	s := p.convert(text)
+	return &markdownDoc{html: s, meta: meta, headings: p.headings}, nil
+}
+
+// splitFrontMatter splits yaml front matter between "---" lines from the body
+func splitFrontMatter(text string) (fm string, body string, ok bool) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, false
+	}
+	rest := text[4:]
+	for i := 0; i <= len(rest); {
+		end := strings.IndexByte(rest[i:], '\n')
+		var line string
+		if end < 0 {
+			line = rest[i:]
+			end = len(rest) - i
+		} else {
+			line = rest[i : i+end]
+		}
+		if l := strings.TrimRight(line, " "); l == "---" || l == "..." {
+			next := min(i+end+1, len(rest))
+			return rest[:i], rest[next:], true
+		}
+		i += end + 1
+	}
+	return "", text, false
+}
+
+// markdownParser converts CommonMark with GitHub's tables and strikethrough
+type markdownParser struct {
+	cfg      MarkdownConfig
+	b        strings.Builder
+	refs     map[string]markdownRef
+	ids      map[string]int
+	headings []MarkdownHeading
+
+	tightPara bool // last block was a paragraph in tight list, written without <p>
+}
+
+type markdownRef struct {
+	url   string
+	title string
+}
+
+func newMarkdownParser(cfg MarkdownConfig) *markdownParser {
+	return &markdownParser{
+		cfg:  cfg,
+		refs: make(map[string]markdownRef),
+		ids:  make(map[string]int),
+	}
+}
+
+func (p *markdownParser) convert(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = expandTabs(l)
+	}
+	p.blocks(p.collectRefs(lines), false)
+	return p.b.String()
+}
+
+var (
+	mdRefDefRe     = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:\s*<?([^\s>]+)>?(?:\s+(?:"([^"]*)"|'([^']*)'|\(([^)]*)\)))?\s*$`)
+	mdEntityRe     = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	mdAutolinkRe   = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.\-]{1,31}:[^\s<>]*)>`)
+	mdEmailRe      = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_` + "`" + `{|}~\-]+@[A-Za-z0-9](?:[A-Za-z0-9\-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9\-]{0,61}[A-Za-z0-9])?)*)>`)
+	mdInlineHTMLRe = regexp.MustCompile(`^<(?:/?[A-Za-z][A-Za-z0-9\-]*(?:\s+[A-Za-z_:][A-Za-z0-9_.:\-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|!--[\s\S]*?-->)`)
+	mdHTMLBlockRe  = regexp.MustCompile(`^<(?:!--|/?([A-Za-z][A-Za-z0-9\-]*)(?:\s|/?>|$))`)
+)
+
+// mdBlockTags are html tags which start html blocks
+var mdBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true,
+	"dialog": true, "div": true, "dl": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "hr": true, "iframe": true,
+	"li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"script": true, "section": true, "style": true, "summary": true, "table": true,
+	"tbody": true, "td": true, "tfoot": true, "th": true, "thead": true, "tr": true,
+	"ul": true,
+}
+
+var mdEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// collectRefs removes link reference definitions from lines
+func (p *markdownParser) collectRefs(lines []string) []string {
+	rs := lines[:0:0]
+	fence := ""
+	prevBlank := true
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if fence != "" {
+			if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				fence = ""
+			}
+			rs = append(rs, l)
+			continue
+		}
+		if indentOf(l) < 4 && isMarkdownFence(t) {
+			fence = t[:fenceLen(t)]
+		}
+		if prevBlank {
+			if m := mdRefDefRe.FindStringSubmatch(l); m != nil {
+				label := normalizeMarkdownLabel(m[1])
+				if _, ok := p.refs[label]; !ok {
+					p.refs[label] = markdownRef{url: m[2], title: m[3] + m[4] + m[5]}
+				}
+				continue
+			}
+		}
+		prevBlank = t == ""
+		rs = append(rs, l)
+	}
+	return rs
+}
+
+func (p *markdownParser) blocks(lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlank(line) {
+			i++
+			continue
+		}
+		if p.tightPara {
+			p.b.WriteByte('\n')
+			p.tightPara = false
+		}
+
+		indent := indentOf(line)
+		if indent >= 4 {
+			i = p.indentedCode(lines, i)
+			continue
+		}
+		s := line[indent:]
+
+		if isMarkdownFence(s) {
+			i = p.fencedCode(lines, i)
+			continue
+		}
+		if level, text, ok := atxHeading(s); ok {
+			p.heading(level, text)
+			i++
+			continue
+		}
+		if isMarkdownHR(s) {
+			p.b.WriteString("<hr />\n")
+			i++
+			continue
+		}
+		if s[0] == '>' {
+			i = p.blockquote(lines, i)
+			continue
+		}
+		if _, ok := parseListMarker(line); ok {
+			i = p.list(lines, i)
+			continue
+		}
+		if isHTMLBlock(s) {
+			i = p.htmlBlock(lines, i)
+			continue
+		}
+		if i+1 < len(lines) && strings.Contains(s, "|") {
+			if aligns, ok := tableDelimRow(lines[i+1]); ok && len(splitTableRow(s)) == len(aligns) {
+				i = p.table(lines, i, aligns)
+				continue
+			}
+		}
+		i = p.paragraph(lines, i, tight)
+	}
+}
+
+// startsBlock reports whether the line starts a block which interrupts paragraph
+func startsBlock(line string) bool {
+	indent := indentOf(line)
+	if indent >= 4 {
+		return false
+	}
+	s := line[indent:]
+	if s == "" {
+		return false
+	}
+	if _, _, ok := atxHeading(s); ok {
+		return true
+	}
+	if isMarkdownFence(s) || isMarkdownHR(s) || s[0] == '>' || isHTMLBlock(s) {
+		return true
+	}
+	if m, ok := parseListMarker(line); ok && m.content != "" && (!m.ordered || m.start == 1) {
+		return true
+	}
+	return false
+}
+
+func (p *markdownParser) heading(level int, text string) {
+	s := p.inline(text)
+	h := MarkdownHeading{Level: level, Text: markdownPlainText(s)}
+	tag := "h" + strconv.Itoa(level)
+	if p.cfg.Anchors {
+		h.ID = p.anchor(h.Text)
+		p.b.WriteString("<" + tag + ` id="` + mdEscaper.Replace(h.ID) + `">`)
+	} else {
+		p.b.WriteString("<" + tag + ">")
+	}
+	p.b.WriteString(s)
+	p.b.WriteString("</" + tag + ">\n")
+	p.headings = append(p.headings, h)
+}
+
+// anchor returns unique id for the heading's text
+func (p *markdownParser) anchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteByte('-')
+		}
+	}
+	id := b.String()
+	if id == "" {
+		id = "section"
+	}
+	if n, ok := p.ids[id]; ok {
+		p.ids[id] = n + 1
+		id += "-" + strconv.Itoa(n+1)
+	}
+	p.ids[id] = 0
+	return id
+}
+
+func (p *markdownParser) paragraph(lines []string, i int, tight bool) int {
+	var text []string
+	for i < len(lines) {
+		l := lines[i]
+		if isBlank(l) {
+			break
+		}
+		if len(text) > 0 && indentOf(l) < 4 {
+			if level := setextLevel(strings.TrimSpace(l)); level > 0 {
+				p.heading(level, strings.Join(text, "\n"))
+				return i + 1
+			}
+			if startsBlock(l) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(l, " "))
+		i++
+	}
+
+	s := p.inline(strings.TrimRight(strings.Join(text, "\n"), " "))
+	if tight {
+		p.b.WriteString(s)
+		p.tightPara = true
+		return i
+	}
+	p.b.WriteString("<p>")
+	p.b.WriteString(s)
+	p.b.WriteString("</p>\n")
+	return i
+}
+
+func (p *markdownParser) indentedCode(lines []string, i int) int {
+	var code []string
+	for i < len(lines) && (isBlank(lines[i]) || indentOf(lines[i]) >= 4) {
+		code = append(code, trimIndent(lines[i], 4))
+		i++
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	p.b.WriteString("<pre><code>")
+	p.b.WriteString(mdEscaper.Replace(strings.Join(code, "\n") + "\n"))
+	p.b.WriteString("</code></pre>\n")
+	return i
+}
+
+func (p *markdownParser) fencedCode(lines []string, i int) int {
+	indent := indentOf(lines[i])
+	s := lines[i][indent:]
+	n := fenceLen(s)
+	fence := s[:n]
+	info := strings.TrimSpace(s[n:])
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		l := lines[i]
+		if t := strings.TrimSpace(l); indentOf(l) < 4 && strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+			i++
+			break
+		}
+		code = append(code, trimIndent(l, indent))
+	}
+
+	p.b.WriteString("<pre><code")
+	if lang, _, _ := strings.Cut(info, " "); lang != "" {
+		p.b.WriteString(` class="language-` + mdEscaper.Replace(unescapeMarkdown(lang)) + `"`)
+	}
+	p.b.WriteString(">")
+	if len(code) > 0 {
+		p.b.WriteString(mdEscaper.Replace(strings.Join(code, "\n") + "\n"))
+	}
+	p.b.WriteString("</code></pre>\n")
+	return i
+}
+
+func (p *markdownParser) blockquote(lines []string, i int) int {
+	var inner []string
+	for i < len(lines) {
+		l := lines[i]
+		if isBlank(l) {
+			break
+		}
+		indent := indentOf(l)
+		if indent < 4 && l[indent] == '>' {
+			s := l[indent+1:]
+			s = strings.TrimPrefix(s, " ")
+			inner = append(inner, s)
+			i++
+			continue
+		}
+		// lazy continuation of paragraph
+		if len(inner) == 0 || isBlank(inner[len(inner)-1]) || startsBlock(l) {
+			break
+		}
+		inner = append(inner, l)
+		i++
+	}
+
+	p.b.WriteString("<blockquote>\n")
+	p.blocks(inner, false)
+	p.endTightPara()
+	p.b.WriteString("</blockquote>\n")
+	return i
+}
+
+type markdownListMarker struct {
+	ordered bool
+	ch      byte // bullet char, or delimiter of ordered list
+	start   int
+	width   int // indent of item's content
+	content string
+}
+
+func parseListMarker(line string) (m markdownListMarker, ok bool) {
+	indent := indentOf(line)
+	if indent >= 4 {
+		return m, false
+	}
+	s := line[indent:]
+	if s == "" {
+		return m, false
+	}
+
+	var n int
+	if strings.IndexByte("-*+", s[0]) >= 0 {
+		m.ch = s[0]
+		n = 1
+	} else {
+		for n < len(s) && n < 9 && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(s) || (s[n] != '.' && s[n] != ')') {
+			return m, false
+		}
+		m.ordered = true
+		m.start, _ = strconv.Atoi(s[:n])
+		m.ch = s[n]
+		n++
+	}
+
+	rest := s[n:]
+	if strings.TrimSpace(rest) == "" {
+		m.width = indent + n + 1
+		return m, true
+	}
+	if rest[0] != ' ' {
+		return m, false
+	}
+	spaces := indentOf(rest)
+	if spaces > 4 {
+		// content starts with indented code
+		spaces = 1
+	}
+	m.width = indent + n + spaces
+	m.content = rest[spaces:]
+	return m, true
+}
+
+func (p *markdownParser) list(lines []string, i int) int {
+	first, _ := parseListMarker(lines[i])
+
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		m, ok := parseListMarker(lines[i])
+		if !ok || m.ordered != first.ordered || m.ch != first.ch || isMarkdownHR(strings.TrimSpace(lines[i])) {
+			break
+		}
+
+		item := []string{m.content}
+		for i++; i < len(lines); {
+			l := lines[i]
+			if isBlank(l) {
+				k := i
+				for k < len(lines) && isBlank(lines[k]) {
+					k++
+				}
+				if k < len(lines) && indentOf(lines[k]) >= m.width {
+					for ; i < k; i++ {
+						item = append(item, "")
+					}
+					continue
+				}
+				break
+			}
+			if indentOf(l) >= m.width {
+				item = append(item, l[m.width:])
+				i++
+				continue
+			}
+			// lazy continuation of paragraph
+			if _, ok := parseListMarker(l); ok {
+				break
+			}
+			if last := item[len(item)-1]; last == "" || startsBlock(l) || startsBlock(last) {
+				break
+			}
+			item = append(item, strings.TrimLeft(l, " "))
+			i++
+		}
+		if isLooseItem(item) {
+			loose = true
+		}
+		items = append(items, item)
+
+		k := i
+		for k < len(lines) && isBlank(lines[k]) {
+			k++
+		}
+		if k > i {
+			if k == len(lines) {
+				i = k
+				break
+			}
+			if next, ok := parseListMarker(lines[k]); !ok || next.ordered != first.ordered || next.ch != first.ch {
+				break
+			}
+			loose = true
+			i = k
+		}
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	p.b.WriteString("<" + tag)
+	if first.ordered && first.start != 1 {
+		p.b.WriteString(` start="` + strconv.Itoa(first.start) + `"`)
+	}
+	p.b.WriteString(">\n")
+	for _, item := range items {
+		p.b.WriteString("<li>")
+		if loose {
+			p.b.WriteString("\n")
+			p.blocks(item, false)
+		} else {
+			p.blocks(item, true)
+			p.tightPara = false
+		}
+		p.b.WriteString("</li>\n")
+	}
+	p.b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// endTightPara ends paragraph in tight list before closing tag
+func (p *markdownParser) endTightPara() {
+	if p.tightPara {
+		p.b.WriteByte('\n')
+		p.tightPara = false
+	}
+}
+
+// isLooseItem reports whether blank line separates the item's direct children
+func isLooseItem(item []string) bool {
+	fence := ""
+	for i := 1; i < len(item); i++ {
+		t := strings.TrimSpace(item[i])
+		if fence != "" {
+			if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if isMarkdownFence(t) {
+			fence = t[:fenceLen(t)]
+			continue
+		}
+		if item[i] != "" && isBlank(item[i-1]) && indentOf(item[i]) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *markdownParser) htmlBlock(lines []string, i int) int {
+	s := strings.TrimLeft(lines[i], " ")
+	end := ""
+	switch {
+	case strings.HasPrefix(s, "<!--"):
+		end = "-->"
+	default:
+		m := mdHTMLBlockRe.FindStringSubmatch(s)
+		switch tag := strings.ToLower(m[1]); tag {
+		case "pre", "script", "style", "textarea":
+			end = "</" + tag + ">"
+		}
+	}
+
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		if end == "" && isBlank(l) {
+			break
+		}
+		p.b.WriteString(l)
+		p.b.WriteByte('\n')
+		if end != "" && strings.Contains(strings.ToLower(l), end) {
+			i++
+			break
+		}
+	}
+	return i
+}
+
+func (p *markdownParser) table(lines []string, i int, aligns []string) int {
+	header := splitTableRow(strings.TrimSpace(lines[i]))
+	i += 2
+
+	p.b.WriteString("<table>\n<thead>\n")
+	p.tableRow(header, aligns, "th")
+	p.b.WriteString("</thead>\n")
+
+	body := false
+	for i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]) {
+		if !body {
+			p.b.WriteString("<tbody>\n")
+			body = true
+		}
+		p.tableRow(splitTableRow(strings.TrimSpace(lines[i])), aligns, "td")
+		i++
+	}
+	if body {
+		p.b.WriteString("</tbody>\n")
+	}
+	p.b.WriteString("</table>\n")
+	return i
+}
+
+func (p *markdownParser) tableRow(cells []string, aligns []string, tag string) {
+	p.b.WriteString("<tr>\n")
+	for i, align := range aligns {
+		p.b.WriteString("<" + tag)
+		if align != "" {
+			p.b.WriteString(` align="` + align + `"`)
+		}
+		p.b.WriteString(">")
+		if i < len(cells) {
+			p.b.WriteString(p.inline(cells[i]))
+		}
+		p.b.WriteString("</" + tag + ">\n")
+	}
+	p.b.WriteString("</tr>\n")
+}
+
+// tableDelimRow returns columns' alignment from table's delimiter row
+func tableDelimRow(line string) ([]string, bool) {
+	s := strings.TrimSpace(line)
+	if indentOf(line) >= 4 || !strings.ContainsAny(s, "|:-") {
+		return nil, false
+	}
+	cells := splitTableRow(s)
+	if len(cells) == 1 && !strings.Contains(s, "|") {
+		return nil, false
+	}
+	aligns := make([]string, len(cells))
+	for i, c := range cells {
+		left := strings.HasPrefix(c, ":")
+		right := strings.HasSuffix(c, ":")
+		d := strings.Trim(c, ":")
+		if d == "" || strings.Trim(d, "-") != "" {
+			return nil, false
+		}
+		switch {
+		case left && right:
+			aligns[i] = "center"
+		case left:
+			aligns[i] = "left"
+		case right:
+			aligns[i] = "right"
+		}
+	}
+	return aligns, true
+}
+
+// splitTableRow splits row into trimmed cells, pipes in code spans or escaped are not split
+func splitTableRow(s string) []string {
+	s = strings.TrimPrefix(s, "|")
+	if strings.HasSuffix(s, "|") && !strings.HasSuffix(s, `\|`) {
+		s = s[:len(s)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	code := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			code = !code
+			cell.WriteByte(c)
+		case c == '|' && !code:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// markdownInline is a text or a delimiter run of emphasis
+type markdownInline struct {
+	text   string // html
+	delim  byte
+	n      int // remaining delimiters
+	orig   int
+	open   bool
+	close  bool
+	before string // closing tags
+	after  string // opening tags
+}
+
+func (p *markdownParser) inline(s string) string {
+	var nodes []*markdownInline
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &markdownInline{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '\\':
+			switch {
+			case i+1 < len(s) && s[i+1] == '\n':
+				text.WriteString("<br />\n")
+				i += 2
+			case i+1 < len(s) && isASCIIPunct(s[i+1]):
+				text.WriteString(mdEscaper.Replace(s[i+1 : i+2]))
+				i += 2
+			default:
+				text.WriteByte('\\')
+				i++
+			}
+		case '`':
+			n := runLen(s, i, '`')
+			if end := findCodeClose(s, i+n, n); end >= 0 {
+				text.WriteString("<code>" + mdEscaper.Replace(normalizeCodeSpan(s[i+n:end])) + "</code>")
+				i = end + n
+			} else {
+				text.WriteString(s[i : i+n])
+				i += n
+			}
+		case '*', '_', '~':
+			n := runLen(s, i, c)
+			if c == '~' && n != 2 {
+				text.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			prev, _ := utf8.DecodeLastRuneInString(s[:i])
+			if i == 0 {
+				prev = ' '
+			}
+			next, _ := utf8.DecodeRuneInString(s[i+n:])
+			if i+n >= len(s) {
+				next = ' '
+			}
+			left := !unicode.IsSpace(next) && (!isMarkdownPunct(next) || unicode.IsSpace(prev) || isMarkdownPunct(prev))
+			right := !unicode.IsSpace(prev) && (!isMarkdownPunct(prev) || unicode.IsSpace(next) || isMarkdownPunct(next))
+			x := &markdownInline{delim: c, n: n, orig: n, open: left, close: right}
+			if c == '_' {
+				x.open = left && (!right || isMarkdownPunct(prev))
+				x.close = right && (!left || isMarkdownPunct(next))
+			}
+			flush()
+			nodes = append(nodes, x)
+			i += n
+		case '!', '[':
+			start := i
+			if c == '!' {
+				if i+1 >= len(s) || s[i+1] != '[' {
+					text.WriteByte('!')
+					i++
+					continue
+				}
+				start++
+			}
+			if h, end, ok := p.link(s, start, c == '!'); ok {
+				text.WriteString(h)
+				i = end
+				continue
+			}
+			text.WriteByte(c)
+			i++
+		case '<':
+			if m := mdAutolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				text.WriteString(`<a href="` + escapeMarkdownURL(m[1]) + `">` + mdEscaper.Replace(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := mdEmailRe.FindStringSubmatch(s[i:]); m != nil {
+				text.WriteString(`<a href="mailto:` + escapeMarkdownURL(m[1]) + `">` + mdEscaper.Replace(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := mdInlineHTMLRe.FindString(s[i:]); m != "" {
+				text.WriteString(m)
+				i += len(m)
+			} else {
+				text.WriteString("&lt;")
+				i++
+			}
+		case '&':
+			if m := mdEntityRe.FindString(s[i:]); m != "" {
+				text.WriteString(m)
+				i += len(m)
+			} else {
+				text.WriteString("&amp;")
+				i++
+			}
+		case ' ':
+			n := runLen(s, i, ' ')
+			switch {
+			case i+n < len(s) && s[i+n] == '\n':
+				if n >= 2 {
+					text.WriteString("<br />")
+				}
+			case i+n == len(s):
+			default:
+				text.WriteString(s[i : i+n])
+			}
+			i += n
+		case '\n':
+			text.WriteByte('\n')
+			i++
+			for i < len(s) && s[i] == ' ' {
+				i++
+			}
+		default:
+			text.WriteString(mdEscaper.Replace(s[i : i+1]))
+			i++
+		}
+	}
+	flush()
+
+	markdownEmphasis(nodes)
+
+	var b strings.Builder
+	for _, x := range nodes {
+		if x.delim == 0 {
+			b.WriteString(x.text)
+			continue
+		}
+		b.WriteString(x.before)
+		b.WriteString(strings.Repeat(string(x.delim), x.n))
+		b.WriteString(x.after)
+	}
+	return b.String()
+}
+
+// markdownEmphasis matches delimiter runs into emphasis
+func markdownEmphasis(nodes []*markdownInline) {
+	for ci, c := range nodes {
+		if c.delim == 0 || !c.close {
+			continue
+		}
+		for c.n > 0 {
+			oi := -1
+			for k := ci - 1; k >= 0; k-- {
+				o := nodes[k]
+				if o.delim != c.delim || !o.open || o.n == 0 {
+					continue
+				}
+				if c.delim == '~' && o.n != c.n {
+					continue
+				}
+				if (o.close || c.open) && (o.orig+c.orig)%3 == 0 && (o.orig%3 != 0 || c.orig%3 != 0) {
+					continue
+				}
+				oi = k
+				break
+			}
+			if oi < 0 {
+				break
+			}
+
+			o := nodes[oi]
+			use, tag := 1, "em"
+			switch {
+			case c.delim == '~':
+				use, tag = 2, "del"
+			case o.n >= 2 && c.n >= 2:
+				use, tag = 2, "strong"
+			}
+			o.n -= use
+			c.n -= use
+			o.after = "<" + tag + ">" + o.after
+			c.before += "</" + tag + ">"
+
+			// delimiters between can not match anymore
+			for k := oi + 1; k < ci; k++ {
+				nodes[k].open = false
+				nodes[k].close = false
+			}
+		}
+	}
+}
+
+// link parses link or image at s[i] == '[', returns html and the index after link
+func (p *markdownParser) link(s string, i int, image bool) (string, int, bool) {
+	end := -1
+	depth := 0
+loop:
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			n := runLen(s, j, '`')
+			if k := findCodeClose(s, j+n, n); k >= 0 {
+				j = k + n - 1
+			} else {
+				j += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = j
+				break loop
+			}
+		}
+	}
+	if end < 0 {
+		return "", 0, false
+	}
+
+	label := s[i+1 : end]
+	k := end + 1
+	var ref markdownRef
+	ok := false
+	if k < len(s) && s[k] == '(' {
+		ref.url, ref.title, k, ok = parseLinkDest(s, k+1)
+	}
+	if !ok && k < len(s) && s[k] == '[' {
+		if close := strings.IndexByte(s[k+1:], ']'); close >= 0 {
+			name := s[k+1 : k+1+close]
+			if name == "" {
+				name = label
+			}
+			ref, ok = p.refs[normalizeMarkdownLabel(name)]
+			k += close + 2
+		}
+	}
+	if !ok {
+		ref, ok = p.refs[normalizeMarkdownLabel(label)]
+		k = end + 1
+	}
+	if !ok {
+		return "", 0, false
+	}
+
+	title := ""
+	if ref.title != "" {
+		title = ` title="` + mdEscaper.Replace(ref.title) + `"`
+	}
+	if image {
+		alt := markdownPlainText(p.inline(label))
+		return `<img src="` + escapeMarkdownURL(ref.url) + `" alt="` + mdEscaper.Replace(alt) + `"` + title + " />", k, true
+	}
+	return `<a href="` + escapeMarkdownURL(ref.url) + `"` + title + ">" + p.inline(label) + "</a>", k, true
+}
+
+// parseLinkDest parses inline link's destination and title after "(",
+// returns the index after ")"
+func parseLinkDest(s string, i int) (dest string, title string, end int, ok bool) {
+	skipSpace := func() {
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+
+	skipSpace()
+	if i < len(s) && s[i] == '<' {
+		j := strings.IndexAny(s[i+1:], ">\n")
+		if j < 0 || s[i+1+j] != '>' {
+			return "", "", 0, false
+		}
+		dest = s[i+1 : i+1+j]
+		i += j + 2
+	} else {
+		start := i
+		depth := 0
+	dest:
+		for ; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					break dest
+				}
+				depth--
+			case ' ', '\n':
+				break dest
+			}
+		}
+		dest = s[start:min(i, len(s))]
+	}
+
+	skipSpace()
+	if i < len(s) && strings.IndexByte(`"'(`, s[i]) >= 0 {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		j := strings.IndexByte(s[i+1:], closer)
+		if j < 0 {
+			return "", "", 0, false
+		}
+		title = unescapeMarkdown(s[i+1 : i+1+j])
+		i += j + 2
+		skipSpace()
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeMarkdown(dest), title, i + 1, true
+}
+
+func escapeMarkdownURL(s string) string {
+	return mdEscaper.Replace(strings.ReplaceAll(s, " ", "%20"))
+}
+
+// markdownPlainText returns text of html
+func markdownPlainText(s string) string {
+	var b strings.Builder
+	tag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			tag = true
+		case r == '>' && tag:
+			tag = false
+		case !tag:
+			b.WriteRune(r)
+		}
+	}
+	return html.UnescapeString(b.String())
+}
+
+func normalizeMarkdownLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func unescapeMarkdown(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func normalizeCodeSpan(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) >= 2 && s[0] == ' ' && s[len(s)-1] == ' ' && strings.Trim(s, " ") != "" {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+// findCodeClose returns the index of backtick run with length n from i, or -1
+func findCodeClose(s string, i int, n int) int {
+	for i < len(s) {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		j += i
+		m := runLen(s, j, '`')
+		if m == n {
+			return j
+		}
+		i = j + m
+	}
+	return -1
+}
+
+func runLen(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isMarkdownPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isMarkdownFence(s string) bool {
+	n := fenceLen(s)
+	if n < 3 {
+		return false
+	}
+	return s[0] != '`' || !strings.Contains(s[n:], "`")
+}
+
+func fenceLen(s string) int {
+	if s == "" || (s[0] != '`' && s[0] != '~') {
+		return 0
+	}
+	return runLen(s, 0, s[0])
+}
+
+func isMarkdownHR(s string) bool {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	return len(s) >= 3 && strings.IndexByte("-*_", s[0]) >= 0 && strings.Trim(s, s[:1]) == ""
+}
+
+func atxHeading(s string) (level int, text string, ok bool) {
+	level = runLen(s, 0, '#')
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := s[level:]
+	if rest != "" && rest[0] != ' ' {
+		return 0, "", false
+	}
+	text = strings.TrimSpace(rest)
+	if t := strings.TrimRight(text, "#"); t == "" || strings.HasSuffix(t, " ") {
+		text = strings.TrimSpace(t)
+	}
+	return level, text, true
+}
+
+func setextLevel(s string) int {
+	switch {
+	case s == "":
+		return 0
+	case strings.Trim(s, "=") == "":
+		return 1
+	case strings.Trim(s, "-") == "":
+		return 2
+	}
+	return 0
+}
+
+func isHTMLBlock(s string) bool {
+	m := mdHTMLBlockRe.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	return m[1] == "" || mdBlockTags[strings.ToLower(m[1])]
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
+
+func indentOf(s string) int {
+	return runLen(s, 0, ' ')
+}
+
+// trimIndent removes up to n spaces of indent
+func trimIndent(s string, n int) string {
+	return s[min(indentOf(s), n):]
+}
+
+// expandTabs expands tabs in the line's indent to spaces
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case ' ':
+			b.WriteByte(' ')
+			col++
+		default:
+			b.WriteString(s[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownConvert(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{"paragraph", "hello\nworld\n\nnext", "<p>hello\nworld</p>\n<p>next</p>\n"},
+		{"escape", `a < b & "c" &amp; \*d\*`, "<p>a &lt; b &amp; &quot;c&quot; &amp; *d*</p>\n"},
+		{"atx heading", "# Title #\n###### six", "<h1>Title</h1>\n<h6>six</h6>\n"},
+		{"setext heading", "Title\n=====\nSub\n---", "<h1>Title</h1>\n<h2>Sub</h2>\n"},
+		{"emphasis", "*a* _b_ **c** __d__ ***e*** ~~f~~", "<p><em>a</em> <em>b</em> <strong>c</strong> <strong>d</strong> <em><strong>e</strong></em> <del>f</del></p>\n"},
+		{"intraword underscore", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"unmatched emphasis", "2 * 3 * 4 and **a", "<p>2 * 3 * 4 and **a</p>\n"},
+		{"code span", "use `` a`b `` and `<x>`", "<p>use <code>a`b</code> and <code>&lt;x&gt;</code></p>\n"},
+		{"link", `[hime](https://github.com/moonrhythm/hime "Hime") [*x*](/a b)`, "<p><a href=\"https://github.com/moonrhythm/hime\" title=\"Hime\">hime</a> [<em>x</em>](/a b)</p>\n"},
+		{"image", "![a *logo*](/logo.png)", "<p><img src=\"/logo.png\" alt=\"a logo\" /></p>\n"},
+		{"reference link", "[docs] and [guide][g]\n\n[docs]: /docs\n[G]: /guide 'Guide'", "<p><a href=\"/docs\">docs</a> and <a href=\"/guide\" title=\"Guide\">guide</a></p>\n"},
+		{"autolink", "<https://example.com> <me@example.com>", "<p><a href=\"https://example.com\">https://example.com</a> <a href=\"mailto:me@example.com\">me@example.com</a></p>\n"},
+		{"inline html", "a <span class=\"x\">b</span>", "<p>a <span class=\"x\">b</span></p>\n"},
+		{"hard break", "a  \nb\\\nc", "<p>a<br />\nb<br />\nc</p>\n"},
+		{"hr", "a\n\n***\n\n- - -", "<p>a</p>\n<hr />\n<hr />\n"},
+		{"fenced code", "```go\nfmt.Println(\"<hi>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&quot;&lt;hi&gt;&quot;)\n</code></pre>\n"},
+		{"indented code", "    a\n\n    b\n", "<pre><code>a\n\nb\n</code></pre>\n"},
+		{"blockquote", "> # q\n> a\nlazy", "<blockquote>\n<h1>q</h1>\n<p>a\nlazy</p>\n</blockquote>\n"},
+		{"tight list", "- a\n- b\n  - c\n- d", "<ul>\n<li>a</li>\n<li>b\n<ul>\n<li>c</li>\n</ul>\n</li>\n<li>d</li>\n</ul>\n"},
+		{"loose list", "1. a\n\n2. b", "<ol>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ol>\n"},
+		{"ordered start", "3) a\n4) b", "<ol start=\"3\">\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"list item paragraphs", "- a\n\n  b\n- c", "<ul>\n<li>\n<p>a</p>\n<p>b</p>\n</li>\n<li>\n<p>c</p>\n</li>\n</ul>\n"},
+		{"html block", "<div>\n*raw*\n</div>\n\n*md*", "<div>\n*raw*\n</div>\n<p><em>md</em></p>\n"},
+		{"table", "| a | b |\n|:--|--:|\n| `x\\|y` | 2 |", "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\"><code>x|y</code></td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.out, string(tfMarkdown(c.in)))
+		})
+	}
+}
+
+func TestMarkdownDoc(t *testing.T) {
+	t.Parallel()
+
+	doc, err := convertMarkdownDoc("---\ntitle: Hello\ntags: [a, b]\n---\n# Hello\n## Hello\n## Setup & *Run*", MarkdownConfig{Anchors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"title": "Hello", "tags": []any{"a", "b"}}, doc.meta)
+	assert.Equal(t, []MarkdownHeading{
+		{Level: 1, ID: "hello", Text: "Hello"},
+		{Level: 2, ID: "hello-1", Text: "Hello"},
+		{Level: 2, ID: "setup--run", Text: "Setup & Run"},
+	}, doc.headings)
+	assert.Equal(t, "<h1 id=\"hello\">Hello</h1>\n<h2 id=\"hello-1\">Hello</h2>\n<h2 id=\"setup--run\">Setup &amp; <em>Run</em></h2>\n", doc.html)
+
+	doc, err = convertMarkdownDoc("---\nnot front matter", MarkdownConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, doc.meta)
+	assert.Equal(t, "<hr />\n<p>not front matter</p>\n", doc.html)
+
+	_, err = convertMarkdownDoc("---\n: [\n---\n", MarkdownConfig{})
+	assert.Error(t, err)
+}
+
+func TestMarkdownView(t *testing.T) {
+	t.Parallel()
+
+	t.Run("config", func(t *testing.T) {
+		app := New()
+		app.Template().ParseConfigFile("testdata/markdown/config.yaml")
+
+		assert.Equal(t,
+			`<title>Introduction</title>`+
+				`<nav><a href="#getting-started">Getting Started</a><a href="#usage">Usage</a></nav>`+
+				`<main><h1 id="getting-started">Getting Started</h1>`+"\n"+
+				`<p>Install with <code>go get</code>.</p>`+"\n"+
+				`<h2 id="usage">Usage</h2>`+"\n"+
+				`<p>Use {{template}} freely.</p>`+"\n"+
+				`</main>`,
+			strings.TrimSpace(renderView(app, "intro")),
+		)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderComponent(&b, "notice", nil))
+		assert.Equal(t, "<p><strong>Note:</strong> read the docs.</p>\n", b.String())
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.Content("body")
+		tp.ParseLayout("plain", `<article data-title="{{frontMatter.title}}">{{template "body" .}}</article>`)
+		tp.ParseMarkdown("about", "---\ntitle: About\n---\n_about_")
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, NewAppContext(app, w, r).Layout("plain").View("about", nil))
+		assert.Equal(t, "<article data-title=\"About\"><p><em>about</em></p>\n</article>", w.Body.String())
+
+		w = httptest.NewRecorder()
+		assert.NoError(t, NewAppContext(app, w, r).NoLayout().View("about", nil))
+		assert.Equal(t, "<p><em>about</em></p>\n", w.Body.String())
+	})
+
+	t.Run("func", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("page", `<div>{{markdown .}}</div>{{if not toc}}no toc{{end}}`)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "page", "*hi*"))
+		assert.Equal(t, "<div><p><em>hi</em></p>\n</div>no toc", b.String())
+	})
+}
@@ -0,0 +1,217 @@
+package hime
+
+import (
+	"bytes"
+	"html/template"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/html"
+)
+
+// preMinifyDelims are the delims of markers which stand for actions
+// while minifying template text
+var preMinifyDelims = [2]string{"{{", "}}"}
+
+const preMinifyMarker = "{{hime:"
+
+// newPreMinifier returns the minifier for template text,
+// or nil when the html minifier does not support template delims
+func newPreMinifier(cfg TemplateMinifyConfig) *minify.M {
+	h, ok := cfg.HTML.(*html.Minifier)
+	if !ok {
+		return nil
+	}
+
+	hm := *h
+	hm.TemplateDelims = preMinifyDelims
+
+	m := minify.New()
+	m.Add("text/html", &hm)
+	if cfg.CSS != nil {
+		m.Add("text/css", cfg.CSS)
+	}
+	if cfg.JS != nil {
+		m.Add("application/javascript", cfg.JS)
+	}
+	return m
+}
+
+// preMinify minifies the text nodes of every template in t's set,
+// so the rendered output does not need to be minified.
+//
+// It reports false when some template's text can not be minified ahead of time,
+// those templates are left unchanged and the output must be minified when render.
+func preMinify(m *minify.M, t *template.Template) bool {
+	ok := true
+	for _, x := range t.Templates() {
+		if x.Tree == nil || x.Tree.Root == nil {
+			continue
+		}
+		if !preMinifyList(m, x.Tree.Root) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// preMinifyList minifies text nodes in list as one html document,
+// every other node is replaced by a marker which the minifier keeps as template code,
+// then the minified document is split at the markers back into the text nodes
+func preMinifyList(m *minify.M, list *parse.ListNode) bool {
+	var (
+		doc     bytes.Buffer
+		texts   [][]*parse.TextNode // text nodes before each marker
+		current []*parse.TextNode
+		unsafe  bool
+	)
+
+	var walk func(list *parse.ListNode)
+	marker := func() {
+		doc.WriteString(preMinifyMarker + strconv.Itoa(len(texts)) + "}}")
+		texts = append(texts, current)
+		current = nil
+	}
+	walk = func(list *parse.ListNode) {
+		if list == nil {
+			return
+		}
+		for _, n := range list.Nodes {
+			switch n := n.(type) {
+			case *parse.TextNode:
+				if bytes.Contains(n.Text, []byte(preMinifyDelims[0])) || bytes.Contains(n.Text, []byte(preMinifyDelims[1])) {
+					unsafe = true
+				}
+				doc.Write(n.Text)
+				current = append(current, n)
+			case *parse.IfNode:
+				walkBranch(&n.BranchNode, walk, marker)
+			case *parse.RangeNode:
+				walkBranch(&n.BranchNode, walk, marker)
+			case *parse.WithNode:
+				walkBranch(&n.BranchNode, walk, marker)
+			default:
+				marker()
+			}
+		}
+	}
+	walk(list)
+	if unsafe {
+		return false
+	}
+	texts = append(texts, current)
+
+	src := doc.String()
+	if hasRawTextMarker(src) {
+		return false
+	}
+	out, err := m.String("text/html", src)
+	if err != nil {
+		return false
+	}
+
+	// split minified document at markers
+	segments := make([]string, len(texts))
+	for i := 0; i < len(texts)-1; i++ {
+		mk := preMinifyMarker + strconv.Itoa(i) + "}}"
+		p := strings.Index(out, mk)
+		if p < 0 {
+			return false
+		}
+		segments[i] = out[:p]
+		out = out[p+len(mk):]
+	}
+	segments[len(texts)-1] = out
+
+	for i, nodes := range texts {
+		seg := segments[i]
+		if len(nodes) == 0 {
+			if seg != "" {
+				return false
+			}
+			continue
+		}
+		if i == 0 {
+			seg = keepBoundarySpace(nodes[0].Text, seg, true)
+		}
+		if i == len(texts)-1 {
+			seg = keepBoundarySpace(nodes[len(nodes)-1].Text, seg, false)
+		}
+		segments[i] = seg
+	}
+
+	for i, nodes := range texts {
+		for j, n := range nodes {
+			if j == 0 {
+				n.Text = []byte(segments[i])
+			} else {
+				n.Text = nil
+			}
+		}
+	}
+	return true
+}
+
+func walkBranch(n *parse.BranchNode, walk func(*parse.ListNode), marker func()) {
+	marker()
+	walk(n.List)
+	if n.ElseList != nil {
+		marker()
+		walk(n.ElseList)
+	}
+	marker()
+}
+
+// keepBoundarySpace keeps a space at the start (or end) of a template's text
+// when the text is inline content, since the minifier does not know
+// what the template is rendered next to
+func keepBoundarySpace(orig []byte, seg string, start bool) string {
+	if len(orig) == 0 {
+		return seg
+	}
+	if start {
+		if isSpace(orig[0]) && (seg == "" || !isSpace(seg[0]) && seg[0] != '<') {
+			return " " + seg
+		}
+		return seg
+	}
+	if isSpace(orig[len(orig)-1]) && (seg == "" || !isSpace(seg[len(seg)-1]) && seg[len(seg)-1] != '>') {
+		return seg + " "
+	}
+	return seg
+}
+
+// rawTextTags are elements which content is not html,
+// or which whitespaces are significant
+var rawTextTags = []string{"script", "style", "textarea", "pre"}
+
+// hasRawTextMarker reports whether any marker is inside a raw text element,
+// the minifier can not minify their content around template code
+func hasRawTextMarker(src string) bool {
+	lower := strings.ToLower(src)
+	for _, tag := range rawTextTags {
+		s := lower
+		for {
+			i := strings.Index(s, "<"+tag)
+			if i < 0 {
+				break
+			}
+			s = s[i+1:]
+			j := strings.Index(s, "</"+tag)
+			if j < 0 {
+				j = len(s)
+			}
+			if strings.Contains(s[:j], preMinifyMarker) {
+				return true
+			}
+			s = s[j:]
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
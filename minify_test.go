@@ -0,0 +1,143 @@
+package hime
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tdewolff/minify/v2/html"
+)
+
+func executeTmpl(t *testing.T, x *tmpl, data any) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	err := x.Execute(&b, data)
+	assert.NoError(t, err)
+	return b.String()
+}
+
+func TestPreMinify(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"A": "a<",
+		"L": []string{"1", "2"},
+	}
+
+	cases := []struct {
+		Name     string
+		Text     string
+		Result   string
+		Minified bool
+	}{
+		{"Text", "  <h1>  Test   </h1>  ", "<h1>Test</h1>", true},
+		{"Action", `<p class="{{.A}}">  Hello   {{.A}}  world </p>`, `<p class="a&lt;">Hello a&lt; world`, true},
+		{"If", `<div>  {{if .A}}  <b> yes </b>  {{else}} no {{end}}  </div>`, `<div> <b>yes </b></div>`, true},
+		{"Range", `<ol> {{range .L}}<li>{{.}}</li>{{end}} </ol>`, `<ol><li>1<li>2</ol>`, true},
+		{"Define", `{{define "x"}}  <b> {{.}} </b>  {{end}}<div> {{template "x" .A}} </div>`, `<div><b>a&lt;</b></div>`, true},
+		{"Style", `<style> p  { color : red } </style><b>{{.A}}</b>`, `<style>p{color:red}</style><b>a&lt;</b>`, true},
+		{"Inline", `  {{.A}}  `, ` a&lt; `, true},
+		{"Inline template", `{{define "x"}} {{.}}{{end}}<p>a{{template "x" .A}}`, `<p>a a&lt;`, true},
+		{"Script with action", `<script>  var x = {{.A}};  var  y = 1; </script>`, `<script>var x="a<",y=1</script>`, false},
+		{"Textarea with action", `<textarea>  a  {{.A}} </textarea>`, `<textarea>  a  a&lt; </textarea>`, false},
+		{"Pre with action", `<pre>  a  {{.A}} </pre>`, `<pre>  a  a&lt; </pre>`, false},
+		{"Delims in text", `<p> {{"{{"}} </p>`, `<p>{{`, true},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			tp := New().Template()
+			tp.Minify()
+			tp.Parse("t", c.Text)
+
+			x := tp.list["t"]
+			assert.Equal(t, c.Result, executeTmpl(t, x, data))
+			assert.Equal(t, c.Minified, x.c.Load().minified)
+		})
+	}
+
+	t.Run("Custom delims", func(t *testing.T) {
+		tp := New().Template()
+		tp.Delims("[[", "]]")
+		tp.Minify()
+		tp.Parse("t", "  <p>  [[.A]]  {{ x }}  </p>")
+
+		x := tp.list["t"]
+		assert.Equal(t, "<p>a&lt; {{ x }}", executeTmpl(t, x, data))
+		assert.False(t, x.c.Load().minified)
+	})
+
+	t.Run("Custom minifier", func(t *testing.T) {
+		tp := New().Template()
+		tp.MinifyWith(TemplateMinifyConfig{
+			HTML: customMinifier{&html.Minifier{}},
+		})
+		tp.Parse("t", "  <h1>  Test   </h1>")
+
+		x := tp.list["t"]
+		assert.Equal(t, "<h1>Test</h1>", executeTmpl(t, x, nil))
+		assert.False(t, x.c.Load().minified)
+	})
+
+	t.Run("Component", func(t *testing.T) {
+		tp := New().Template()
+		tp.Minify()
+		tp.ParseComponent("c", "  <b>  {{.}}  </b>  ")
+
+		x := tp.components["c"]
+		assert.Equal(t, "<b>a&lt;</b>", executeTmpl(t, x, "a<"))
+		assert.True(t, x.c.Load().minified)
+	})
+}
+
+type customMinifier struct {
+	*html.Minifier
+}
+
+func benchmarkMinify(b *testing.B, pre bool) {
+	tp := New().Template()
+	tp.Minify()
+	if !pre {
+		tp.preMinifier = nil
+	}
+	tp.Parse("t", `<!doctype html>
+<html>
+  <head>
+    <title>  {{.Title}}  </title>
+    <style>
+      body { margin : 0 ; padding : 0 }
+    </style>
+  </head>
+  <body>
+    <h1>  {{.Title}}  </h1>
+    <ul>
+      {{range .Items}}
+        <li class="item">  {{.}}  </li>
+      {{end}}
+    </ul>
+  </body>
+</html>`)
+
+	x := tp.list["t"]
+	data := map[string]any{
+		"Title": "Benchmark",
+		"Items": []string{"a", "b", "c", "d", "e", "f", "g", "h"},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Execute(io.Discard, data)
+	}
+}
+
+func BenchmarkPreMinify(b *testing.B) {
+	benchmarkMinify(b, true)
+}
+
+func BenchmarkRuntimeMinify(b *testing.B) {
+	benchmarkMinify(b, false)
+}
@@ -0,0 +1,158 @@
+package hime
+
+import (
+	"html/template"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pageLayoutName is the name (without extension) of a directory's layout file in pages
+const pageLayoutName = "_layout"
+
+// ParsePages loads every file in the directory as a view,
+// named by its path relative to the directory without extension,
+// e.g. "admin/users.tmpl" is loaded as "admin/users".
+//
+// Each view is combined with layouts, then "_layout" files
+// from the directory down to the view's directory, so a directory's layout
+// overrides templates defined by the outer layouts.
+// Directory layouts are named by their path, e.g. "pages/admin/_layout.tmpl".
+// Other files and directories starting with "_" are not views.
+func (tp *Template) ParsePages(dir string, layouts ...string) {
+	root := path.Join(tp.dir, dir)
+	if root == "" {
+		root = "."
+	}
+	layoutFiles := joinTemplateDir(tp.dir, layouts...)
+
+	pages, dirLayouts := scanPages(tp.fs, root)
+	for _, page := range pages {
+		var pageLayouts, pageLayoutNames []string
+		for _, d := range pageDirs(page) {
+			if l, ok := dirLayouts[d]; ok {
+				pageLayouts = append(pageLayouts, l)
+				pageLayoutNames = append(pageLayoutNames, path.Join(dir, d, path.Base(filepath.ToSlash(l))))
+			}
+		}
+
+		name := strings.TrimSuffix(page, path.Ext(page))
+		tp.parsePage(name, layoutFiles, pageLayouts, pageLayoutNames, joinPagePath(tp.fs, root, page))
+	}
+}
+
+// parsePage loads the page with layouts and directory layouts,
+// directory layouts are named by their path (e.g. "pages/admin/_layout.tmpl")
+// since they have the same file name
+func (tp *Template) parsePage(name string, layouts []string, dirLayouts []string, dirLayoutNames []string, file string) {
+	fsys := tp.fs
+	root := tp.root
+	md, content := tp.markdown, tp.content
+	view := path.Base(filepath.ToSlash(file))
+
+	sources := make(templateSources)
+	sources.addFiles(fsys, layouts...)
+	for i, l := range dirLayouts {
+		sources[dirLayoutNames[i]] = templateSource{fs: fsys, path: l}
+	}
+	sources.addFiles(fsys, file)
+	sources.markdown(md)
+
+	tp.newTemplate(name, view, sources, func(t *template.Template) *template.Template {
+		t = parseTemplateFiles(t, fsys, layouts, md)
+		for i, l := range dirLayouts {
+			text, err := templateSource{fs: fsys, path: l}.read()
+			if err != nil {
+				panic(err)
+			}
+			template.Must(t.New(dirLayoutNames[i]).Parse(text))
+		}
+		t = parseTemplateFiles(t, fsys, []string{file}, md, content)
+		if root == "" {
+			t = t.Lookup(view)
+		}
+		return t
+	})
+}
+
+// scanPages returns pages and layout files keyed by directory,
+// relative to root with forward slashes
+func scanPages(fsys fs.FS, root string) (pages []string, layouts map[string]string) {
+	layouts = make(map[string]string)
+
+	walk := func(rel string, d fs.DirEntry) error {
+		name := d.Name()
+		if d.IsDir() {
+			if rel != "." && strings.HasPrefix(name, "_") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.TrimSuffix(name, path.Ext(name)) == pageLayoutName {
+			layouts[path.Dir(rel)] = rel
+			return nil
+		}
+		if strings.HasPrefix(name, "_") {
+			return nil
+		}
+		pages = append(pages, rel)
+		return nil
+	}
+
+	var err error
+	if fsys == nil {
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			return walk(filepath.ToSlash(rel), d)
+		})
+	} else {
+		err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+			if rel == "" {
+				rel = "."
+			}
+			return walk(rel, d)
+		})
+	}
+	if err != nil {
+		panicf("parse pages; %v", err)
+	}
+
+	for d, l := range layouts {
+		layouts[d] = joinPagePath(fsys, root, l)
+	}
+	sort.Strings(pages)
+	return pages, layouts
+}
+
+// pageDirs returns directories of the page from pages root to the page's directory
+func pageDirs(page string) []string {
+	dirs := []string{"."}
+	dir := path.Dir(page)
+	if dir == "." {
+		return dirs
+	}
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+	}
+	return dirs
+}
+
+func joinPagePath(fsys fs.FS, root, rel string) string {
+	if fsys == nil {
+		return filepath.Join(root, filepath.FromSlash(rel))
+	}
+	return path.Join(root, rel)
+}
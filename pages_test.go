@@ -0,0 +1,102 @@
+package hime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePages(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, app *App) {
+		t.Helper()
+
+		assert.Len(t, app.template, 4)
+		assert.Equal(t, "<html><title>site</title><body>index</body></html>", renderView(app, "index"))
+		assert.Equal(t, "<html><title>about</title><body>about us</body></html>", renderView(app, "about"))
+		assert.Equal(t, "<admin>dashboard</admin>", renderView(app, "admin/index"))
+		assert.Equal(t, "<admin>users</admin>", renderView(app, "admin/users/list"))
+	}
+
+	t.Run("config", func(t *testing.T) {
+		app := New()
+		app.ParseConfigFile("testdata/pages/config.yaml")
+		check(t, app)
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.Dir("testdata/pages")
+		tp.Root("root")
+		tp.ParsePages("pages", "layout.tmpl")
+		check(t, app)
+	})
+
+	t.Run("fs", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.FS(os.DirFS("testdata/pages"))
+		tp.Root("root")
+		tp.ParsePages("pages", "layout.tmpl")
+		check(t, app)
+	})
+
+	t.Run("without root", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.Dir("testdata/pages/pages/admin")
+		tp.ParsePages("users")
+
+		assert.Len(t, app.template, 1)
+		assert.Equal(t, "", renderView(app, "list"))
+	})
+
+	t.Run("nested layouts", func(t *testing.T) {
+		dir := t.TempDir()
+		now := time.Now()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "pages/admin/users"), 0755))
+		writeTemplateFile(t, filepath.Join(dir, "pages/_layout.tmpl"), `{{define "root"}}<main>{{template "nav"}}|{{template "body"}}</main>{{end}}{{define "nav"}}site{{end}}`, now)
+		writeTemplateFile(t, filepath.Join(dir, "pages/admin/_layout.tmpl"), `{{define "nav"}}admin{{end}}`, now)
+		writeTemplateFile(t, filepath.Join(dir, "pages/admin/users/_layout.tmpl"), `{{define "body"}}users{{end}}`, now)
+		writeTemplateFile(t, filepath.Join(dir, "pages/admin/users/list.tmpl"), ``, now)
+
+		app := New()
+		app.TemplateReload = true
+		tp := app.Template()
+		tp.Dir(dir)
+		tp.Root("root")
+		tp.ParsePages("pages")
+
+		assert.Equal(t, "<main>admin|users</main>", renderView(app, "admin/users/list"))
+		for _, name := range []string{"pages/_layout.tmpl", "pages/admin/_layout.tmpl", "pages/admin/users/_layout.tmpl"} {
+			assert.Contains(t, app.template["admin/users/list"].sources, name)
+		}
+
+		// the outer layout is reloaded
+		writeTemplateFile(t, filepath.Join(dir, "pages/_layout.tmpl"), `{{define "root"}}<div>{{template "nav"}}|{{template "body"}}</div>{{end}}`, now.Add(time.Second))
+		assert.Equal(t, "<div>admin|users</div>", renderView(app, "admin/users/list"))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.Dir("testdata/pages")
+		tp.Root("root")
+		tp.ParseFiles("about", "layout.tmpl", "pages/about.tmpl")
+
+		assert.PanicsWithError(t, "hime: template 'about' already exists", func() {
+			tp.ParsePages("pages", "layout.tmpl")
+		})
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		assert.Panics(t, func() {
+			New().Template().ParsePages("testdata/notfound")
+		})
+	})
+}
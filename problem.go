@@ -0,0 +1,148 @@
+package hime
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Problem is the problem details object for HTTP APIs (RFC 9457)
+//
+// Problem implements error, so a Handler can return it
+// to respond with the problem document.
+type Problem struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+
+	// Extensions are additional members of the problem document,
+	// members named as a standard member are ignored
+	Extensions map[string]any
+}
+
+func (p *Problem) Error() string {
+	title := p.Title
+	if title == "" {
+		title = http.StatusText(p.Status)
+	}
+	if p.Detail != "" {
+		return title + ": " + p.Detail
+	}
+	return title
+}
+
+// Set sets an extension member
+func (p *Problem) Set(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// SetFormErrors sets form state's validation errors as the "errors" extension member,
+// keyed by field name
+func (p *Problem) SetFormErrors(fs *FormState) *Problem {
+	errs := make(map[string][]string, len(fs.errors))
+	for k, v := range fs.errors {
+		errs[k] = append([]string(nil), v...)
+	}
+	return p.Set("errors", errs)
+}
+
+// MarshalJSON implements json.Marshaler
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	setOrDelete := func(key string, value any, empty bool) {
+		if empty {
+			delete(m, key)
+			return
+		}
+		m[key] = value
+	}
+	setOrDelete("type", p.Type, p.Type == "")
+	setOrDelete("title", p.Title, p.Title == "")
+	setOrDelete("status", p.Status, p.Status == 0)
+	setOrDelete("detail", p.Detail, p.Detail == "")
+	setOrDelete("instance", p.Instance, p.Instance == "")
+	return json.Marshal(m)
+}
+
+// Problem writes the problem document as application/problem+json,
+// status and title are filled from the context's error status when empty
+func (ctx *Context) Problem(p *Problem) error {
+	x := *p
+	if x.Status == 0 {
+		x.Status = ctx.statusCodeError()
+	}
+	if x.Title == "" {
+		x.Title = http.StatusText(x.Status)
+	}
+
+	ctx.setContentType("application/problem+json")
+	return ctx.Status(x.Status).JSON(&x)
+}
+
+// PrefersJSON reports whether the request's Accept header
+// prefers a json response over html
+func (ctx *Context) PrefersJSON() bool {
+	accept := ctx.Request.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+	jsonQ := acceptQuality(accept, "application", "json")
+	if q := acceptQuality(accept, "application", "problem+json"); q > jsonQ {
+		jsonQ = q
+	}
+	return jsonQ > 0 && jsonQ > acceptQuality(accept, "text", "html")
+}
+
+// acceptQuality returns the quality value of the media type from accept header,
+// using the most specific matching media range
+func acceptQuality(accept string, typ, subtype string) float64 {
+	q := 0.0
+	specificity := -1
+	for _, r := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(r, ";")
+		t, st, _ := strings.Cut(strings.ToLower(strings.TrimSpace(mediaRange)), "/")
+
+		var s int
+		switch {
+		case t == typ && st == subtype:
+			s = 2
+		case t == typ && st == "*":
+			s = 1
+		case t == "*" && st == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+		specificity = s
+		q = acceptParamQuality(params)
+	}
+	return q
+}
+
+func acceptParamQuality(params string) float64 {
+	for _, p := range strings.Split(params, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if strings.ToLower(k) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
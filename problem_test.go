@@ -0,0 +1,152 @@
+package hime
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextProblem(t *testing.T) {
+	t.Parallel()
+
+	t.Run("members", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+		ctx := NewAppContext(New(), w, r)
+
+		p := &Problem{
+			Type:     "https://example.com/probs/out-of-credit",
+			Title:    "You do not have enough credit.",
+			Status:   http.StatusForbidden,
+			Detail:   "Your current balance is 30, but that costs 50.",
+			Instance: "/account/12345/msgs/abc",
+		}
+		p.Set("balance", 30).Set("status", "ignored")
+
+		assert.NoError(t, ctx.Problem(p))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "https://example.com/probs/out-of-credit",
+			"title": "You do not have enough credit.",
+			"status": 403,
+			"detail": "Your current balance is 30, but that costs 50.",
+			"instance": "/account/12345/msgs/abc",
+			"balance": 30
+		}`, w.Body.String())
+	})
+
+	t.Run("defaults from status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx := NewAppContext(New(), w, r)
+
+		p := &Problem{}
+		assert.NoError(t, ctx.Status(http.StatusConflict).Problem(p))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"title":"Conflict","status":409}`, w.Body.String())
+		assert.Zero(t, p.Status, "must not mutate given problem")
+	})
+
+	t.Run("form errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("email=bad"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := NewAppContext(New(), w, r)
+
+		fs := ctx.FormState()
+		fs.AddError("email", "invalid email")
+
+		p := &Problem{Status: http.StatusUnprocessableEntity}
+		assert.NoError(t, ctx.Problem(p.SetFormErrors(fs)))
+		assert.JSONEq(t, `{
+			"title": "Unprocessable Entity",
+			"status": 422,
+			"errors": {"email": ["invalid email"]}
+		}`, w.Body.String())
+	})
+}
+
+func TestProblemError(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Not Found", (&Problem{Status: http.StatusNotFound}).Error())
+	assert.Equal(t, "Out of credit: balance is 30", (&Problem{Title: "Out of credit", Detail: "balance is 30"}).Error())
+}
+
+func TestContextPrefersJSON(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		Accept string
+		JSON   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", true},
+		{"application/problem+json", true},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"application/json, text/html;q=0.5", true},
+		{"application/json;q=0.5, text/html", false},
+		{"application/*", true},
+		{"application/json;q=0", false},
+		{"text/plain", false},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", c.Accept)
+		ctx := NewAppContext(New(), httptest.NewRecorder(), r)
+		assert.Equal(t, c.JSON, ctx.PrefersJSON(), c.Accept)
+	}
+}
+
+func TestHandlerProblem(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returned problem", func(t *testing.T) {
+		app := New()
+		app.Handler(Handler(func(ctx *Context) error {
+			return fmt.Errorf("create: %w", &Problem{Status: http.StatusConflict, Detail: "already exists"})
+		}))
+
+		w := invokeHandler(app, "GET", "/", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"title":"Conflict","status":409,"detail":"already exists"}`, w.Body.String())
+	})
+
+	t.Run("json request", func(t *testing.T) {
+		app := New()
+		app.Handler(Handler(func(ctx *Context) error {
+			return &HTTPError{Status: http.StatusNotFound, Message: "no such order"}
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"title":"Not Found","status":404,"detail":"no such order"}`, w.Body.String())
+	})
+
+	t.Run("html request", func(t *testing.T) {
+		app := New()
+		app.Handler(Handler(func(ctx *Context) error {
+			return &HTTPError{Status: http.StatusNotFound, Message: "no such order"}
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "no such order\n", w.Body.String())
+	})
+}
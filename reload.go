@@ -0,0 +1,110 @@
+package hime
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+func (app *App) lookupTemplate(name string) (*tmpl, bool) {
+	t, ok := app.template[name]
+	if ok && app.TemplateReload {
+		t.reload(app.logf)
+	}
+	return t, ok
+}
+
+func (app *App) lookupComponent(name string) (*tmpl, bool) {
+	t, ok := app.component[name]
+	if ok && app.TemplateReload {
+		t.reload(app.logf)
+	}
+	return t, ok
+}
+
+// reloadTemplates reloads all modified views and components,
+// it reports whether any template was reloaded
+func (app *App) reloadTemplates() bool {
+	reloaded := false
+	for _, t := range app.template {
+		if t.reload(app.logf) {
+			reloaded = true
+		}
+	}
+	for _, t := range app.component {
+		if t.reload(app.logf) {
+			reloaded = true
+		}
+	}
+	return reloaded
+}
+
+// reload re-parses the template when any of its source files was modified,
+// parse errors are logged once and the current template is kept until the sources are modified again
+func (t *tmpl) reload(logf func(format string, a ...any)) bool {
+	if t.rebuild == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	modTime := t.sources.modTime()
+	if !modTime.After(t.modTime) || modTime.Equal(t.errModTime) {
+		return false
+	}
+
+	nt, err := t.tryRebuild()
+	if !t.sources.modTime().Equal(modTime) {
+		// sources were modified while reading (e.g. an editor saving in place),
+		// the result may be partial, read again on next reload
+		return false
+	}
+	if err != nil {
+		t.errModTime = modTime
+		logf("hime: reload template '%s'; %v", t.name, err)
+		return false
+	}
+	t.modTime = modTime
+	t.c.Store(nt)
+	return true
+}
+
+func (t *tmpl) tryRebuild() (nt *compiledTmpl, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return t.rebuild(), nil
+}
+
+// modTime returns the latest modification time of the source files
+func (xs templateSources) modTime() time.Time {
+	var r time.Time
+	for _, s := range xs {
+		if m := s.modTime(); m.After(r) {
+			r = m
+		}
+	}
+	return r
+}
+
+func (s templateSource) modTime() time.Time {
+	if s.path == "" {
+		return time.Time{}
+	}
+
+	var fi fs.FileInfo
+	var err error
+	if s.fs == nil {
+		fi, err = os.Stat(s.path)
+	} else {
+		fi, err = fs.Stat(s.fs, s.path)
+	}
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,153 @@
+package hime
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTemplateFile writes the file and moves its mtime forward,
+// so the change is visible even within the filesystem's time resolution
+func writeTemplateFile(t *testing.T, filename, content string, mtime time.Time) {
+	t.Helper()
+
+	// write to a temp file then rename,
+	// so concurrent reload never reads a partially written file
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(tmp, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func renderView(app *App, name string) string {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	NewAppContext(app, w, r).View(name, nil)
+	return w.Body.String()
+}
+
+func TestTemplateReload(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (*App, string) {
+		dir := t.TempDir()
+		now := time.Now()
+		writeTemplateFile(t, filepath.Join(dir, "layout.tmpl"), `{{define "layout"}}<main>{{template "body"}}</main>{{end}}`, now)
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}{{version}} {{template "footer"}}{{end}}`, now)
+		writeTemplateFile(t, filepath.Join(dir, "footer.tmpl"), `{{define "footer"}}f1{{end}}`, now)
+		writeTemplateFile(t, filepath.Join(dir, "card.tmpl"), `c1`, now)
+
+		app := New()
+		app.srv.ErrorLog = log.New(io.Discard, "", 0)
+		app.TemplateReload = true
+		tp := app.Template()
+		tp.Dir(dir)
+		tp.Func("version", func() string { return "v1" })
+		tp.Parse("plain", "plain")
+		tp.Root("layout")
+		tp.Preload("footer.tmpl")
+		tp.ParseFiles("index", "index.tmpl", "layout.tmpl")
+		tp.ParseComponentFile("card", "card.tmpl")
+		tp.Root("")
+		tp.Parse("withCard", `{{component "card"}}`)
+		return app, dir
+	}
+
+	t.Run("view file", func(t *testing.T) {
+		app, dir := setup(t)
+		assert.Equal(t, `<main>v1 f1</main>`, renderView(app, "index"))
+
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}{{version}}2 {{template "footer"}}{{end}}`, time.Now().Add(time.Second))
+		assert.Equal(t, `<main>v12 f1</main>`, renderView(app, "index"))
+		assert.Equal(t, "plain", renderView(app, "plain"))
+	})
+
+	t.Run("preload file", func(t *testing.T) {
+		app, dir := setup(t)
+
+		writeTemplateFile(t, filepath.Join(dir, "footer.tmpl"), `{{define "footer"}}f2{{end}}`, time.Now().Add(time.Second))
+		assert.Contains(t, renderView(app, "index"), "f2")
+	})
+
+	t.Run("component file", func(t *testing.T) {
+		app, dir := setup(t)
+		assert.Equal(t, "c1", renderView(app, "withCard"))
+
+		writeTemplateFile(t, filepath.Join(dir, "card.tmpl"), `c2`, time.Now().Add(time.Second))
+		assert.Equal(t, "c2", renderView(app, "withCard"))
+	})
+
+	t.Run("parse error keeps current template", func(t *testing.T) {
+		app, dir := setup(t)
+
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}{{if}}{{end}}`, time.Now().Add(time.Second))
+		assert.Equal(t, `<main>v1 f1</main>`, renderView(app, "index"))
+
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}v3{{end}}`, time.Now().Add(2*time.Second))
+		assert.Equal(t, `<main>v3</main>`, renderView(app, "index"))
+	})
+
+	t.Run("modified while reading", func(t *testing.T) {
+		app, dir := setup(t)
+
+		x := app.template["index"]
+		rebuild := x.rebuild
+		x.rebuild = func() *compiledTmpl {
+			c := rebuild()
+			if b, _ := os.ReadFile(filepath.Join(dir, "index.tmpl")); strings.Contains(string(b), "partial") {
+				writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}done{{end}}`, time.Now().Add(2*time.Second))
+			}
+			return c
+		}
+
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}partial{{end}}`, time.Now().Add(time.Second))
+		assert.Equal(t, `<main>v1 f1</main>`, renderView(app, "index"))
+		assert.Equal(t, `<main>done</main>`, renderView(app, "index"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		app, dir := setup(t)
+		app.TemplateReload = false
+
+		writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}v2{{end}}`, time.Now().Add(time.Second))
+		assert.Equal(t, `<main>v1 f1</main>`, renderView(app, "index"))
+
+		assert.True(t, app.reloadTemplates())
+		assert.False(t, app.reloadTemplates())
+		assert.Equal(t, `<main>v2</main>`, renderView(app, "index"))
+	})
+
+	t.Run("concurrent render", func(t *testing.T) {
+		app, dir := setup(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					assert.Contains(t, renderView(app, "index"), "<main>")
+				}
+			}()
+		}
+		for i := 1; i <= 10; i++ {
+			writeTemplateFile(t, filepath.Join(dir, "index.tmpl"), `{{define "body"}}v{{end}}`, time.Now().Add(time.Duration(i)*time.Second))
+		}
+		wg.Wait()
+	})
+}
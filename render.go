@@ -0,0 +1,80 @@
+package hime
+
+import (
+	"context"
+	"io"
+)
+
+// RenderView renders the view into w outside an HTTP request,
+// e.g. for emails or background jobs.
+//
+// It panics if the view is not found, matching Context.View.
+// Context funcs and ViewData are not available without request,
+// except t, locale and format funcs which use the default locale.
+func (app *App) RenderView(w io.Writer, name string, data any) error {
+	return app.RenderViewContext(context.Background(), w, name, data)
+}
+
+// RenderViewContext renders the view into w, see RenderView.
+//
+// Rendering stops with ctx's error at the template's next output when ctx is done.
+func (app *App) RenderViewContext(ctx context.Context, w io.Writer, name string, data any) error {
+	t, ok := app.lookupTemplate(name)
+	if !ok {
+		panic(newErrTemplateNotFound(name))
+	}
+	return renderTmpl(ctx, w, t, data)
+}
+
+// RenderComponent renders the component into w outside an HTTP request, see RenderView.
+//
+// It panics if the component is not found, matching Context.Component.
+func (app *App) RenderComponent(w io.Writer, name string, data any) error {
+	return app.RenderComponentContext(context.Background(), w, name, data)
+}
+
+// RenderComponentContext renders the component into w, see RenderComponent.
+//
+// Rendering stops with ctx's error at the template's next output when ctx is done.
+func (app *App) RenderComponentContext(ctx context.Context, w io.Writer, name string, data any) error {
+	t, ok := app.lookupComponent(name)
+	if !ok {
+		panic(newErrComponentNotFound(name))
+	}
+	return renderTmpl(ctx, w, t, data)
+}
+
+func renderTmpl(ctx context.Context, w io.Writer, t *tmpl, data any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// render into buffer, so w does not get partial output
+	buf := getBytes()
+	defer putBytes(buf)
+
+	err := t.Execute(&contextWriter{ctx: ctx, w: buf}, data)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// contextWriter fails writes when the context is done,
+// so template execution stops at the next write
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *contextWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
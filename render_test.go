@@ -0,0 +1,99 @@
+package hime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderView(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		tp := app.Template()
+		tp.Minify()
+		tp.ParseComponent("c", `<b>  {{.}}  </b>`)
+		tp.Parse("page", `<p>  {{.}}  </p>{{component "c" "x"}}`)
+		tp.Parse("ctx", `{{viewData "A"}}`)
+		return app
+	}
+
+	t.Run("view", func(t *testing.T) {
+		var b strings.Builder
+		assert.NoError(t, newApp().RenderView(&b, "page", "hi"))
+		assert.Equal(t, "<p>hi</p><b>x</b>", b.String())
+	})
+
+	t.Run("component", func(t *testing.T) {
+		var b strings.Builder
+		assert.NoError(t, newApp().RenderComponent(&b, "c", "y"))
+		assert.Equal(t, "<b>y</b>", b.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		app := newApp()
+		assert.Panics(t, func() { app.RenderView(&strings.Builder{}, "missing", nil) })
+		assert.Panics(t, func() { app.RenderComponent(&strings.Builder{}, "missing", nil) })
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var b strings.Builder
+		err := newApp().RenderViewContext(ctx, &b, "page", "hi")
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Empty(t, b.String())
+	})
+
+	t.Run("canceled while render", func(t *testing.T) {
+		app := New()
+		ctx, cancel := context.WithCancel(context.Background())
+		app.TemplateFuncs(map[string]any{
+			"cancel": func() string {
+				cancel()
+				return ""
+			},
+		})
+		app.Template().Parse("page", `a{{cancel}}b`)
+
+		var b strings.Builder
+		err := app.RenderViewContext(ctx, &b, "page", nil)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Empty(t, b.String())
+	})
+
+	t.Run("canceled while render with minify", func(t *testing.T) {
+		app := New()
+		ctx, cancel := context.WithCancel(context.Background())
+		after := 0
+		app.TemplateFuncs(map[string]any{
+			"cancel": func() string {
+				cancel()
+				return ""
+			},
+			"after": func() string {
+				after++
+				return ""
+			},
+		})
+		tp := app.Template()
+		tp.Minify()
+		tp.Parse("page", `<p>a</p>{{cancel}}<p>b</p>{{after}}`)
+
+		var b strings.Builder
+		err := app.RenderViewContext(ctx, &b, "page", nil)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Empty(t, b.String())
+		assert.Equal(t, 0, after)
+	})
+
+	t.Run("context funcs", func(t *testing.T) {
+		err := newApp().RenderView(&strings.Builder{}, "ctx", nil)
+		assert.Error(t, err)
+	})
+}
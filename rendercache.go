@@ -0,0 +1,194 @@
+package hime
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RenderCacheConfig is the config of the cache of templates compiled by Context.Render
+type RenderCacheConfig struct {
+	// Size is the max number of cached templates,
+	// the least recently used template is evicted when full.
+	// Zero is unbounded.
+	Size int
+
+	// TTL is the duration a template is cached since compiled.
+	// Zero never expires.
+	TTL time.Duration
+}
+
+// RenderCacheStats is the stats of Context.Render's cache
+type RenderCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Len       int
+}
+
+// RenderCache sets the config of Context.Render's cache,
+// cached templates are removed.
+//
+// Default is unbounded without expiration.
+func (app *App) RenderCache(cfg RenderCacheConfig) {
+	app.renderCache.configure(cfg)
+}
+
+// RenderCacheStats returns the stats of Context.Render's cache
+func (app *App) RenderCacheStats() RenderCacheStats {
+	return app.renderCache.stats()
+}
+
+// ResetRenderCache removes all templates from Context.Render's cache
+func (app *App) ResetRenderCache() {
+	app.renderCache.reset()
+}
+
+// renderCache is a lru cache of compiled templates,
+// templates are kept in a lock-free map when the cache is unbounded
+type renderCache struct {
+	bounded   atomic.Bool // Size or TTL is set
+	unbounded sync.Map    // key => *compiledTmpl
+	n         atomic.Int64
+
+	mu    sync.Mutex
+	cfg   RenderCacheConfig
+	ll    *list.List
+	items map[string]*list.Element
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type renderCacheEntry struct {
+	key       string
+	t         *compiledTmpl
+	expiresAt time.Time
+}
+
+func (c *renderCache) configure(cfg RenderCacheConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cfg = cfg
+	c.bounded.Store(cfg.Size > 0 || cfg.TTL > 0)
+	c.ll = nil
+	c.items = nil
+	c.unbounded.Clear()
+	c.n.Store(0)
+}
+
+func (c *renderCache) config() RenderCacheConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cfg
+}
+
+func (c *renderCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll = nil
+	c.items = nil
+	c.unbounded.Clear()
+	c.n.Store(0)
+}
+
+func (c *renderCache) stats() RenderCacheStats {
+	c.mu.Lock()
+	n := len(c.items) + int(c.n.Load())
+	c.mu.Unlock()
+
+	return RenderCacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Len:       n,
+	}
+}
+
+func (c *renderCache) get(key string) (*compiledTmpl, bool) {
+	if !c.bounded.Load() {
+		t, ok := c.unbounded.Load(key)
+		if !ok {
+			c.misses.Add(1)
+			return nil, false
+		}
+		c.hits.Add(1)
+		return t.(*compiledTmpl), true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	e := el.Value.(*renderCacheEntry)
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		c.remove(el)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	c.hits.Add(1)
+	return e.t, true
+}
+
+func (c *renderCache) set(key string, t *compiledTmpl) {
+	if !c.bounded.Load() {
+		if _, loaded := c.unbounded.Swap(key, t); !loaded {
+			c.n.Add(1)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.items == nil {
+		c.ll = list.New()
+		c.items = make(map[string]*list.Element)
+	}
+
+	e := &renderCacheEntry{key: key, t: t}
+	if c.cfg.TTL > 0 {
+		e.expiresAt = time.Now().Add(c.cfg.TTL)
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(e)
+
+	if c.cfg.Size > 0 {
+		for c.ll.Len() > c.cfg.Size {
+			c.remove(c.ll.Back())
+		}
+	}
+
+	// remove expired templates which were not used recently
+	now := time.Now()
+	for el := c.ll.Back(); el != nil; el = c.ll.Back() {
+		e := el.Value.(*renderCacheEntry)
+		if e.expiresAt.IsZero() || now.Before(e.expiresAt) {
+			break
+		}
+		c.remove(el)
+	}
+}
+
+func (c *renderCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*renderCacheEntry).key)
+	c.evictions.Add(1)
+}
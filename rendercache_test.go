@@ -0,0 +1,108 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCache(t *testing.T) {
+	t.Parallel()
+
+	render := func(app *App, tmpl string) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.NoError(t, NewAppContext(app, w, r).Render(tmpl, nil))
+		return w.Body.String()
+	}
+
+	t.Run("unbounded by default", func(t *testing.T) {
+		app := New()
+		for i := 0; i < 100; i++ {
+			render(app, strconv.Itoa(i))
+		}
+		assert.Equal(t, "<p>a</p>", render(app, "<p>a</p>"))
+		assert.Equal(t, RenderCacheStats{Misses: 101, Len: 101}, app.RenderCacheStats())
+
+		render(app, "<p>a</p>")
+		assert.Equal(t, uint64(1), app.RenderCacheStats().Hits)
+
+		// lock-free lookup, lru list is not used
+		assert.Nil(t, app.renderCache.items)
+	})
+
+	t.Run("unbounded after bounded", func(t *testing.T) {
+		app := New()
+		app.RenderCache(RenderCacheConfig{Size: 1})
+		render(app, "a")
+		app.RenderCache(RenderCacheConfig{})
+		render(app, "a")
+		render(app, "b")
+		render(app, "a")
+
+		stats := app.RenderCacheStats()
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, 2, stats.Len)
+	})
+
+	t.Run("lru", func(t *testing.T) {
+		app := New()
+		app.RenderCache(RenderCacheConfig{Size: 2})
+
+		render(app, "a")
+		render(app, "b")
+		render(app, "a") // hit, b is least recently used
+		render(app, "c") // evicts b
+		render(app, "a") // hit
+		render(app, "b") // miss, evicts c
+
+		assert.Equal(t, RenderCacheStats{
+			Hits:      2,
+			Misses:    4,
+			Evictions: 2,
+			Len:       2,
+		}, app.RenderCacheStats())
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		app := New()
+		app.RenderCache(RenderCacheConfig{TTL: 20 * time.Millisecond})
+
+		render(app, "a")
+		render(app, "a")
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, "a", render(app, "a"))
+
+		stats := app.RenderCacheStats()
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, uint64(2), stats.Misses)
+		assert.Equal(t, uint64(1), stats.Evictions)
+		assert.Equal(t, 1, stats.Len)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		app := New()
+		render(app, "a")
+		app.ResetRenderCache()
+		assert.Equal(t, 0, app.RenderCacheStats().Len)
+
+		render(app, "a")
+		assert.Equal(t, uint64(2), app.RenderCacheStats().Misses)
+	})
+
+	t.Run("clone keeps config", func(t *testing.T) {
+		app := New()
+		app.RenderCache(RenderCacheConfig{Size: 1})
+		render(app, "a")
+
+		x := app.Clone()
+		assert.Equal(t, RenderCacheStats{}, x.RenderCacheStats())
+		render(x, "a")
+		render(x, "b")
+		assert.Equal(t, 1, x.RenderCacheStats().Len)
+	})
+}
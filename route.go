@@ -41,9 +41,10 @@ func (app *App) Route(name string, params ...any) string {
 	return buildPath(path, params...)
 }
 
-// Route gets route path from name
+// Route gets route path from name,
+// prefixed with the request's locale when i18n prefix is enabled, see I18nConfig
 func (ctx *Context) Route(name string, params ...any) string {
-	return ctx.app.Route(name, params...)
+	return ctx.localePath(ctx.app.Route(name, params...))
 }
 
 // IsRoute reports whether the named route is the most specific registered route
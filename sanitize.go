@@ -0,0 +1,345 @@
+package hime
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	htmllex "github.com/tdewolff/parse/v2/html"
+)
+
+// Sanitize policies
+const (
+	SanitizeStrict = "strict" // text only
+	SanitizeBasic  = "basic"  // text formatting
+	SanitizeUGC    = "ugc"    // basic with headings, lists, tables, links and images
+)
+
+// SanitizePolicy is the allowlist of html elements and attributes
+//
+// Example:
+//
+//	sanitize:
+//	  comment:
+//	    elements:
+//	      p: []
+//	      a: [href]
+//	    linkRel: nofollow noopener
+type SanitizePolicy struct {
+	// Elements are allowed elements to their allowed attributes
+	Elements map[string][]string `yaml:"elements" json:"elements"`
+
+	// Attributes are allowed attributes of all allowed elements
+	Attributes []string `yaml:"attributes" json:"attributes"`
+
+	// Schemes are allowed schemes of urls, default is http, https and mailto
+	Schemes []string `yaml:"schemes" json:"schemes"`
+
+	// LinkRel is set to rel attribute of links, e.g. "nofollow noopener"
+	LinkRel string `yaml:"linkRel" json:"linkRel"`
+}
+
+// sanitizer is a compiled SanitizePolicy
+type sanitizer struct {
+	elements map[string]map[string]bool
+	attrs    map[string]bool
+	schemes  map[string]bool
+	linkRel  string
+}
+
+var basicSanitizeElements = []string{
+	"p", "br", "b", "strong", "i", "em", "u", "s", "del", "ins", "mark",
+	"small", "sub", "sup", "code", "kbd", "pre", "blockquote", "q", "span",
+}
+
+var defaultSanitizers = map[string]*sanitizer{
+	SanitizeStrict: newSanitizer(SanitizePolicy{}),
+	SanitizeBasic:  newSanitizer(SanitizePolicy{Elements: sanitizeElements(basicSanitizeElements...)}),
+	SanitizeUGC: newSanitizer(SanitizePolicy{
+		Elements: mergeSanitizeElements(
+			sanitizeElements(basicSanitizeElements...),
+			sanitizeElements(
+				"h1", "h2", "h3", "h4", "h5", "h6", "hr", "ul", "li", "dl", "dt", "dd",
+				"table", "caption", "thead", "tbody", "tfoot", "tr", "figure", "figcaption",
+				"details", "summary",
+			),
+			map[string][]string{
+				"a":    {"href", "title"},
+				"img":  {"src", "alt", "title", "width", "height"},
+				"ol":   {"start"},
+				"th":   {"colspan", "rowspan", "align"},
+				"td":   {"colspan", "rowspan", "align"},
+				"abbr": {"title"},
+			},
+		),
+		LinkRel: "nofollow noopener",
+	}),
+}
+
+// sanitizeDropContent are elements which content is removed with the element
+var sanitizeDropContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "noscript": true,
+	"template": true, "textarea": true, "title": true, "select": true, "xmp": true,
+	"noembed": true, "noframes": true, "plaintext": true,
+}
+
+// voidElements are elements without content
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// urlAttrs are attributes which value is url
+var urlAttrs = map[string]bool{
+	"href": true, "src": true, "cite": true, "action": true, "formaction": true,
+	"poster": true, "background": true, "longdesc": true, "xlink:href": true,
+}
+
+func sanitizeElements(names ...string) map[string][]string {
+	rs := make(map[string][]string, len(names))
+	for _, name := range names {
+		rs[name] = nil
+	}
+	return rs
+}
+
+func mergeSanitizeElements(xs ...map[string][]string) map[string][]string {
+	rs := make(map[string][]string)
+	for _, x := range xs {
+		for k, v := range x {
+			rs[k] = append(rs[k], v...)
+		}
+	}
+	return rs
+}
+
+func newSanitizer(p SanitizePolicy) *sanitizer {
+	s := &sanitizer{
+		elements: make(map[string]map[string]bool, len(p.Elements)),
+		attrs:    make(map[string]bool, len(p.Attributes)),
+		schemes:  make(map[string]bool),
+		linkRel:  p.LinkRel,
+	}
+	for name, attrs := range p.Elements {
+		m := make(map[string]bool, len(attrs))
+		for _, a := range attrs {
+			m[strings.ToLower(a)] = true
+		}
+		s.elements[strings.ToLower(name)] = m
+	}
+	for _, a := range p.Attributes {
+		s.attrs[strings.ToLower(a)] = true
+	}
+	schemes := p.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https", "mailto"}
+	}
+	for _, x := range schemes {
+		s.schemes[strings.ToLower(x)] = true
+	}
+	return s
+}
+
+// SanitizePolicy registers the named policy, replaces the policy with the same name
+func (app *App) SanitizePolicy(name string, policy SanitizePolicy) {
+	if app.sanitizers == nil {
+		app.sanitizers = make(map[string]*sanitizer)
+	}
+	app.sanitizers[name] = newSanitizer(policy)
+}
+
+// Sanitize removes elements and attributes not allowed by the named policy from html.
+//
+// Event handler attributes are always removed,
+// and urls with schemes not allowed by the policy are removed.
+//
+// Template func {{sanitize .Bio}} sanitizes with SanitizeUGC policy,
+// and {{sanitize "basic" .Bio}} with the named policy.
+func (app *App) Sanitize(policy string, s string) (template.HTML, error) {
+	x := app.sanitizers[policy]
+	if x == nil {
+		x = defaultSanitizers[policy]
+	}
+	if x == nil {
+		return "", newErrSanitizePolicyNotFound(policy)
+	}
+	return template.HTML(x.sanitize(s)), nil
+}
+
+func (app *App) sanitizeFunc(args ...string) (template.HTML, error) {
+	switch len(args) {
+	case 1:
+		return app.Sanitize(SanitizeUGC, args[0])
+	case 2:
+		return app.Sanitize(args[0], args[1])
+	}
+	return "", fmt.Errorf("hime: sanitize wants 1-2 arguments, got %d", len(args))
+}
+
+func cloneSanitizers(xs map[string]*sanitizer) map[string]*sanitizer {
+	if xs == nil {
+		return nil
+	}
+	rs := make(map[string]*sanitizer, len(xs))
+	for k, v := range xs {
+		rs[k] = v
+	}
+	return rs
+}
+
+type sanitizeAttr struct {
+	key string
+	val string
+}
+
+func (s *sanitizer) sanitize(src string) string {
+	var b strings.Builder
+	var stack []string // open elements
+	var (
+		tag   string // allowed start tag, empty when the tag is removed
+		attrs []sanitizeAttr
+	)
+	skip, skipDepth := "", 0 // element which content is removed
+
+	l := htmllex.NewLexer(parse.NewInputString(src))
+	for {
+		tt, data := l.Next()
+		switch tt {
+		case htmllex.ErrorToken:
+			for i := len(stack) - 1; i >= 0; i-- {
+				b.WriteString("</" + stack[i] + ">")
+			}
+			return b.String()
+		case htmllex.StartTagToken:
+			name := string(l.Text())
+			tag = ""
+			attrs = attrs[:0]
+			if skip != "" {
+				if name == skip {
+					skipDepth++
+				}
+				continue
+			}
+			if _, ok := s.elements[name]; ok {
+				tag = name
+				continue
+			}
+			if sanitizeDropContent[name] {
+				skip, skipDepth = name, 1
+			}
+		case htmllex.AttributeToken:
+			if tag == "" {
+				continue
+			}
+			key := string(l.AttrKey())
+			val := html.UnescapeString(unquoteAttr(string(l.AttrVal())))
+			if !s.allowAttr(tag, key, val) {
+				continue
+			}
+			dup := false
+			for _, a := range attrs {
+				dup = dup || a.key == key
+			}
+			if !dup {
+				attrs = append(attrs, sanitizeAttr{key, val})
+			}
+		case htmllex.StartTagCloseToken, htmllex.StartTagVoidToken:
+			if tag == "" {
+				continue
+			}
+			s.writeStartTag(&b, tag, attrs)
+			if !voidElements[tag] {
+				stack = append(stack, tag)
+			}
+			tag = ""
+		case htmllex.EndTagToken:
+			name := strings.ToLower(string(l.Text()))
+			if skip != "" {
+				if name == skip {
+					skipDepth--
+					if skipDepth == 0 {
+						skip = ""
+					}
+				}
+				continue
+			}
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i] != name {
+					continue
+				}
+				for j := len(stack) - 1; j >= i; j-- {
+					b.WriteString("</" + stack[j] + ">")
+				}
+				stack = stack[:i]
+				break
+			}
+		case htmllex.TextToken:
+			if skip == "" {
+				b.WriteString(html.EscapeString(html.UnescapeString(string(data))))
+			}
+		}
+	}
+}
+
+func (s *sanitizer) writeStartTag(b *strings.Builder, tag string, attrs []sanitizeAttr) {
+	hasURL := false
+	for _, a := range attrs {
+		hasURL = hasURL || urlAttrs[a.key]
+	}
+	if tag == "img" && !hasURL {
+		return
+	}
+
+	b.WriteString("<" + tag)
+	for _, a := range attrs {
+		if tag == "a" && a.key == "rel" && s.linkRel != "" {
+			continue
+		}
+		b.WriteString(" " + a.key + `="` + html.EscapeString(a.val) + `"`)
+	}
+	if tag == "a" && hasURL && s.linkRel != "" {
+		b.WriteString(` rel="` + html.EscapeString(s.linkRel) + `"`)
+	}
+	b.WriteString(">")
+}
+
+func (s *sanitizer) allowAttr(tag, key, val string) bool {
+	if strings.HasPrefix(key, "on") {
+		return false
+	}
+	if !s.elements[tag][key] && !s.attrs[key] {
+		return false
+	}
+	if key == "srcset" {
+		for _, c := range strings.Split(val, ",") {
+			u, _, _ := strings.Cut(strings.TrimSpace(c), " ")
+			if !s.allowURL(u) {
+				return false
+			}
+		}
+		return true
+	}
+	if urlAttrs[key] {
+		return s.allowURL(val)
+	}
+	return true
+}
+
+// allowURL reports whether the url is relative, or its scheme is allowed
+func (s *sanitizer) allowURL(u string) bool {
+	// browsers ignore whitespace and control characters in url
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	return s.schemes[strings.ToLower(u[:i])]
+}
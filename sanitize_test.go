@@ -0,0 +1,98 @@
+package hime
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		policy string
+		in     string
+		out    string
+	}{
+		{"strict", SanitizeStrict, `<p>Hello <b>world</b> &amp; <i>you</i></p>`, `Hello world &amp; you`},
+		{"strict escapes", SanitizeStrict, `a &lt;b&gt; 1 < 2`, `a &lt;b&gt; 1 &lt; 2`},
+		{"basic", SanitizeBasic, `<p class="x">a <strong>b</strong><br/>c</p>`, `<p>a <strong>b</strong><br>c</p>`},
+		{"basic removes links", SanitizeBasic, `<a href="https://example.com">x</a>`, `x`},
+		{"script", SanitizeUGC, `a<script>alert("x")</script>b<style>p{}</style>c`, `abc`},
+		{"nested drop", SanitizeUGC, `<noscript><noscript>x</noscript>y</noscript>z`, `z`},
+		{"svg", SanitizeUGC, `<svg onload="alert(1)"><circle/></svg>ok`, `ok`},
+		{"comment", SanitizeUGC, `a<!-- <b>x</b> -->b`, `ab`},
+		{"event handler", SanitizeUGC, `<img src="/a.png" onerror="alert(1)" alt="a">`, `<img src="/a.png" alt="a">`},
+		{"link rel", SanitizeUGC, `<a href="https://example.com" rel="me" target="_blank">x</a>`, `<a href="https://example.com" rel="nofollow noopener">x</a>`},
+		{"javascript url", SanitizeUGC, `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"obfuscated url", SanitizeUGC, `<a href=" jav&#x09;ascript:alert(1)">x</a><a href="JAVASCRIPT:x">y</a>`, `<a>x</a><a>y</a>`},
+		{"data image", SanitizeUGC, `<img src="data:image/svg+xml;base64,xx">`, ``},
+		{"relative url", SanitizeUGC, `<a href="/users?id=1&amp;x=2#top">x</a>`, `<a href="/users?id=1&amp;x=2#top" rel="nofollow noopener">x</a>`},
+		{"attribute escape", SanitizeUGC, `<img src=/a.png alt='"><script>'>`, `<img src="/a.png" alt="&#34;&gt;&lt;script&gt;">`},
+		{"unbalanced", SanitizeUGC, `<p><b>a</p>c</b></div>`, `<p><b>a</b></p>c`},
+		{"unclosed", SanitizeUGC, `<ul><li>a`, `<ul><li>a</li></ul>`},
+		{"duplicate attribute", SanitizeUGC, `<a href="/a" href="javascript:x">x</a>`, `<a href="/a" rel="nofollow noopener">x</a>`},
+	}
+
+	app := New()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := app.Sanitize(c.policy, c.in)
+			assert.NoError(t, err)
+			assert.Equal(t, c.out, string(s))
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := New().Sanitize("unknown", "")
+		var notFound *ErrSanitizePolicyNotFound
+		assert.True(t, errors.As(err, &notFound))
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		app := New()
+		app.ParseConfig([]byte(`
+sanitize:
+  comment:
+    elements:
+      p: []
+      a: [href]
+    attributes: [title]
+    schemes: [https]
+  basic:
+    elements:
+      b: []
+`))
+
+		s, err := app.Sanitize("comment", `<p title="t" id="x"><a href="http://a.com">a</a><a href="https://b.com">b</a><b>c</b></p>`)
+		assert.NoError(t, err)
+		assert.Equal(t, `<p title="t"><a>a</a><a href="https://b.com">b</a>c</p>`, string(s))
+
+		s, _ = app.Sanitize(SanitizeBasic, `<b>a</b><i>b</i>`)
+		assert.Equal(t, `<b>a</b>b`, string(s))
+
+		// other apps keep default policy
+		s, _ = New().Sanitize(SanitizeBasic, `<b>a</b><i>b</i>`)
+		assert.Equal(t, `<b>a</b><i>b</i>`, string(s))
+	})
+
+	t.Run("template", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("page", `<div>{{sanitize .}}</div><div>{{. | sanitize "strict"}}</div>`)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderView(&b, "page", `<a href="/x" onclick="x()">x</a>`))
+		assert.Equal(t, `<div><a href="/x" rel="nofollow noopener">x</a></div><div>x</div>`, b.String())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("page", `{{sanitize "unknown" .}}`)
+		err := app.Validate()
+		var notFound *ErrSanitizePolicyNotFound
+		assert.True(t, errors.As(err, &notFound))
+	})
+}
@@ -0,0 +1,372 @@
+package hime
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig is static handler config
+//
+// Example:
+//
+//	static:
+//	  - dir: public
+//	    prefix: /
+//	    cacheControl: public, max-age=3600
+//	    fallback: app
+type StaticConfig struct {
+	// Dir is the directory of files
+	Dir string `yaml:"dir" json:"dir"`
+
+	// Prefix is the url path which the handler is mounted, default is "/"
+	Prefix string `yaml:"prefix" json:"prefix"`
+
+	// CacheControl is Cache-Control header of files, default is "no-cache"
+	CacheControl string `yaml:"cacheControl" json:"cacheControl"`
+
+	// Index is the file served for directories, default is "index.html"
+	Index string `yaml:"index" json:"index"`
+
+	// NotFound is the file served with status 404 when file not found,
+	// see Fallback for static mounted by config
+	NotFound string `yaml:"notFound" json:"notFound"`
+
+	// Fallback is the view rendered when file not found
+	// and the path does not have extension, for single-page apps,
+	// when mounted by config, it is rendered after the app's handler responds 404
+	Fallback string `yaml:"fallback" json:"fallback"`
+}
+
+// staticEncodings are precompressed variants in preference order
+var staticEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"zstd", ".zst"},
+	{"gzip", ".gz"},
+}
+
+type staticHandler struct {
+	app    *App
+	fs     fs.FS
+	prefix string
+	cfg    StaticConfig
+
+	etags sync.Map // file name => *staticETag
+}
+
+type staticETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// Static returns the handler which serves files from the directory, see StaticFS
+func (app *App) Static(cfg StaticConfig) http.Handler {
+	return app.StaticFS(os.DirFS(cfg.Dir), cfg)
+}
+
+// StaticFS returns the handler which serves files from fsys, e.g. embed.FS.
+//
+// Precompressed .br, .zst and .gz siblings of a file are served
+// when the client accepts the encoding.
+// When file not found, the handler serves NotFound file, renders Fallback view,
+// or responds with Context.NotFound.
+func (app *App) StaticFS(fsys fs.FS, cfg StaticConfig) http.Handler {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = "/"
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if cfg.Index == "" {
+		cfg.Index = "index.html"
+	}
+	if cfg.CacheControl == "" {
+		cfg.CacheControl = "no-cache"
+	}
+	return &staticHandler{
+		app:    app,
+		fs:     fsys,
+		prefix: prefix,
+		cfg:    cfg,
+	}
+}
+
+// mountStatic adds the static handler to ServeHandler,
+// requests for files which are not found are passed to the app's handler,
+// NotFound or Fallback is served only when the handler responds 404
+func (app *App) mountStatic(cfg StaticConfig) {
+	app.statics = append(app.statics, app.Static(cfg).(*staticHandler))
+}
+
+// clone returns the handler bound to app
+func (h *staticHandler) clone(app *App) *staticHandler {
+	return &staticHandler{
+		app:    app,
+		fs:     h.fs,
+		prefix: h.prefix,
+		cfg:    h.cfg,
+	}
+}
+
+func (h *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, nil)
+}
+
+// serve serves the file, calls next when file not found, next can be nil
+func (h *staticHandler) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if next != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	p := r.URL.Path
+	if !h.match(p) {
+		h.notFound(w, r, next)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(p, h.prefix)), "/")
+	if name == "" {
+		name = "."
+	}
+
+	fi, err := fs.Stat(h.fs, name)
+	if err == nil && fi.IsDir() {
+		// directories without index are not found
+		name = path.Join(name, h.cfg.Index)
+		fi, err = fs.Stat(h.fs, name)
+		if err == nil && !fi.IsDir() && !strings.HasSuffix(p, "/") {
+			http.Redirect(w, r, path.Base(p)+"/", http.StatusMovedPermanently)
+			return
+		}
+	}
+	if err != nil || fi.IsDir() {
+		h.notFound(w, r, next)
+		return
+	}
+
+	if err := h.serveFile(w, r, name, fi, http.StatusOK); err != nil {
+		h.notFound(w, r, next)
+	}
+}
+
+// match reports whether the path is under the handler's prefix
+func (h *staticHandler) match(p string) bool {
+	return strings.HasPrefix(p, h.prefix) || p+"/" == h.prefix
+}
+
+// notFound serves NotFound file or Fallback view,
+// after next responds 404 when next is not nil
+func (h *staticHandler) notFound(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	fallback := h.cfg.Fallback != "" && path.Ext(r.URL.Path) == ""
+	if next != nil {
+		if h.cfg.NotFound == "" && !fallback {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header().Clone()
+		nw := &notFoundWriter{ResponseWriter: w}
+		next.ServeHTTP(nw, r)
+		if !nw.notFound {
+			return
+		}
+
+		// discard headers set by next's 404 response
+		clear(w.Header())
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+	}
+
+	if h.cfg.NotFound != "" {
+		fi, err := fs.Stat(h.fs, h.cfg.NotFound)
+		if err == nil && !fi.IsDir() {
+			if h.serveFile(w, r, h.cfg.NotFound, fi, http.StatusNotFound) == nil {
+				return
+			}
+		}
+	}
+
+	if fallback {
+		ctx := NewAppContext(h.app, w, r)
+		err := ctx.View(h.cfg.Fallback, nil)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			h.app.handleError(ctx, err)
+		}
+		return
+	}
+
+	NewAppContext(h.app, w, r).NotFound()
+}
+
+// notFoundWriter discards the response when the handler responds 404
+type notFoundWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	notFound    bool
+}
+
+func (w *notFoundWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader && statusCode >= 200 {
+		w.wroteHeader = true
+		w.notFound = statusCode == http.StatusNotFound
+	}
+	if w.notFound {
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *notFoundWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.notFound {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *notFoundWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.notFound {
+		return nil
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *notFoundWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// serveFile serves the file or its precompressed variant
+func (h *staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string, fi fs.FileInfo, status int) error {
+	serveName, encoding := name, ""
+	accept := acceptEncodings(r.Header.Get("Accept-Encoding"))
+	for _, e := range staticEncodings {
+		if !accept[e.encoding] {
+			continue
+		}
+		vfi, err := fs.Stat(h.fs, name+e.ext)
+		if err == nil && !vfi.IsDir() {
+			serveName, encoding, fi = name+e.ext, e.encoding, vfi
+			break
+		}
+	}
+
+	f, err := h.fs.Open(serveName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		rs = bytes.NewReader(content)
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		header.Set("Content-Type", ct)
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	if status != http.StatusOK {
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "text/html; charset=utf-8")
+		}
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+		w.WriteHeader(status)
+		if r.Method != http.MethodHead {
+			io.Copy(w, rs)
+		}
+		return nil
+	}
+
+	etag, err := h.etag(serveName, fi, rs)
+	if err != nil {
+		return err
+	}
+	header.Set("Cache-Control", h.cfg.CacheControl)
+	header.Set("ETag", etag)
+	http.ServeContent(w, r, name, fi.ModTime(), rs)
+	return nil
+}
+
+// etag returns strong etag from content's hash,
+// cached until the file's modification time or size changed
+func (h *staticHandler) etag(name string, fi fs.FileInfo, rs io.ReadSeeker) (string, error) {
+	if x, ok := h.etags.Load(name); ok {
+		e := x.(*staticETag)
+		if e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+			return e.etag, nil
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, rs); err != nil {
+		return "", err
+	}
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	e := &staticETag{
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+		etag:    `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`,
+	}
+	h.etags.Store(name, e)
+	return e.etag, nil
+}
+
+// acceptEncodings returns encodings accepted by Accept-Encoding header
+func acceptEncodings(s string) map[string]bool {
+	rs := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if enc == "" {
+			continue
+		}
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q <= 0 {
+				continue
+			}
+		}
+		rs[enc] = true
+	}
+	return rs
+}
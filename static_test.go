@@ -0,0 +1,206 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatic(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"app.js":          {Data: []byte("var a = 1;")},
+		"app.js.br":       {Data: []byte("br")},
+		"app.js.gz":       {Data: []byte("gz")},
+		"index.html":      {Data: []byte("<h1>home</h1>")},
+		"docs/index.html": {Data: []byte("<h1>docs</h1>")},
+		"404.html":        {Data: []byte("<h1>not found</h1>")},
+	}
+
+	get := func(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("serve", func(t *testing.T) {
+		h := New().StaticFS(fsys, StaticConfig{CacheControl: "public, max-age=3600"})
+
+		w := get(h, "/app.js", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "var a = 1;", w.Body.String())
+		assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+
+		etag := w.Header().Get("ETag")
+		assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+		w = get(h, "/app.js", http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("precompressed", func(t *testing.T) {
+		h := New().StaticFS(fsys, StaticConfig{})
+
+		w := get(h, "/app.js", http.Header{"Accept-Encoding": {"gzip, deflate, br"}})
+		assert.Equal(t, "br", w.Body.String())
+		assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		brETag := w.Header().Get("ETag")
+
+		w = get(h, "/app.js", http.Header{"Accept-Encoding": {"gzip, br;q=0"}})
+		assert.Equal(t, "gz", w.Body.String())
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.NotEqual(t, brETag, w.Header().Get("ETag"))
+
+		w = get(h, "/app.js", http.Header{"Accept-Encoding": {"zstd"}})
+		assert.Equal(t, "var a = 1;", w.Body.String())
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		h := New().StaticFS(fsys, StaticConfig{Prefix: "/public"})
+
+		w := get(h, "/public/", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<h1>home</h1>", w.Body.String())
+
+		w = get(h, "/public/docs/", nil)
+		assert.Equal(t, "<h1>docs</h1>", w.Body.String())
+
+		w = get(h, "/public/docs", nil)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/public/docs/", w.Header().Get("Location"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := get(New().StaticFS(fsys, StaticConfig{}), "/missing.js", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = get(New().StaticFS(fsys, StaticConfig{NotFound: "404.html"}), "/missing", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "<h1>not found</h1>", w.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("app", `<div id="app">{{.}}</div>`)
+		h := app.StaticFS(fsys, StaticConfig{Fallback: "app"})
+
+		w := get(h, "/users/1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `<div id="app"></div>`, w.Body.String())
+
+		w = get(h, "/missing.js", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		h := New().StaticFS(fsys, StaticConfig{})
+		w := invokeHandler(h, http.MethodPost, "/app.js", nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+	})
+
+	t.Run("config", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "robots.txt"), []byte("User-agent: *"), 0o644))
+
+		app := New()
+		app.ParseConfig([]byte(`
+static:
+  - dir: ` + dir + `
+    cacheControl: public, max-age=60
+`))
+		app.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("handler " + r.Method))
+		}))
+
+		w := invokeHandler(app, http.MethodGet, "/robots.txt", nil)
+		assert.Equal(t, "User-agent: *", w.Body.String())
+		assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+
+		w = invokeHandler(app, http.MethodGet, "/users", nil)
+		assert.Equal(t, "handler GET", w.Body.String())
+
+		w = invokeHandler(app, http.MethodPost, "/robots.txt", nil)
+		assert.Equal(t, "handler POST", w.Body.String())
+	})
+
+	t.Run("config fallback", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.js"), []byte("var a = 1;"), 0o644))
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, "admin"), 0o755))
+
+		app := New()
+		app.ParseConfig([]byte(`
+static:
+  - dir: ` + dir + `
+    fallback: app
+`))
+		app.Template().Parse("app", `<div id="{{.id}}"></div>`)
+		app.ViewData = func(ctx *Context) map[string]any {
+			return map[string]any{"id": "app"}
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("login"))
+		})
+		mux.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("admin"))
+		})
+		mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		})
+		app.Handler(mux)
+
+		w := invokeHandler(app, http.MethodGet, "/login", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "login", w.Body.String())
+
+		// directory without index
+		w = invokeHandler(app, http.MethodGet, "/admin", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "admin", w.Body.String())
+
+		w = invokeHandler(app, http.MethodGet, "/forbidden", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "forbidden\n", w.Body.String())
+
+		w = invokeHandler(app, http.MethodGet, "/app.js", nil)
+		assert.Equal(t, "var a = 1;", w.Body.String())
+
+		w = invokeHandler(app, http.MethodGet, "/users/1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `<div id="app"></div>`, w.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+
+		w = invokeHandler(app, http.MethodGet, "/missing.js", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// clone renders with its own view data
+		x := app.Clone()
+		x.ViewData = func(ctx *Context) map[string]any {
+			return map[string]any{"id": "clone"}
+		}
+		w = invokeHandler(x, http.MethodGet, "/users/1", nil)
+		assert.Equal(t, `<div id="clone"></div>`, w.Body.String())
+		w = invokeHandler(app, http.MethodGet, "/users/1", nil)
+		assert.Equal(t, `<div id="app"></div>`, w.Body.String())
+	})
+}
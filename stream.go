@@ -0,0 +1,140 @@
+package hime
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// streamChunkSize is the size of buffered output which triggers a flush
+	streamChunkSize = 32 << 10
+
+	// streamFlushInterval is the max duration buffered output is held
+	// before flush while the template still renders
+	streamFlushInterval = 200 * time.Millisecond
+)
+
+// Stream overrides stream setting
+func (ctx *Context) Stream(enable bool) *Context {
+	ctx.stream = enable
+	return ctx
+}
+
+func (ctx *Context) streaming() bool {
+	return ctx.stream && !ctx.app.liveReloadEnabled()
+}
+
+// streamTmpl renders t directly into the response writer.
+//
+// The output is sent in chunks, if t fails before the first chunk was sent
+// the error is returned as is and nothing is written,
+// otherwise the rendered output is sent and the error is returned as *StreamError.
+//
+// When the whole output fits in the first chunk,
+// the response is written as non-streaming (with etag).
+func (ctx *Context) streamTmpl(t *tmpl, data any) error {
+	sw := streamWriter{
+		ctx:       ctx,
+		buf:       getBytes(),
+		lastFlush: time.Now(),
+	}
+	defer putBytes(sw.buf)
+
+	err := t.Stream(ctx, &sw, data)
+	if sw.err != nil {
+		return filterRenderError(sw.err)
+	}
+	if err != nil {
+		if !sw.started {
+			return err
+		}
+		if ferr := sw.flush(); ferr != nil {
+			return filterRenderError(ferr)
+		}
+		if filterRenderError(err) == nil {
+			return nil
+		}
+		return &StreamError{Err: err}
+	}
+
+	if !sw.started {
+		return ctx.writeHTML(sw.buf)
+	}
+	return filterRenderError(sw.flush())
+}
+
+// streamWriter buffers output and flushes it to the response writer
+// when a chunk is full or after flush interval
+type streamWriter struct {
+	ctx       *Context
+	buf       *bytes.Buffer
+	started   bool
+	lastFlush time.Time
+	err       error // write error from response writer
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, _ := w.buf.Write(p)
+	if w.buf.Len() >= streamChunkSize || time.Since(w.lastFlush) >= streamFlushInterval {
+		if err := w.flush(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (w *streamWriter) flush() error {
+	if !w.started {
+		w.started = true
+		w.ctx.setCSPHeader()
+		w.ctx.setContentType("text/html; charset=utf-8")
+		w.ctx.writeHeader()
+	}
+
+	_, err := w.buf.WriteTo(w.ctx.w)
+	if err == nil {
+		err = http.NewResponseController(w.ctx.w).Flush()
+		if errors.Is(err, http.ErrNotSupported) {
+			err = nil
+		}
+	}
+	w.lastFlush = time.Now()
+	w.err = err
+	return err
+}
+
+// Stream executes the template into w without buffering the whole output,
+// runtime minification uses the minifier's writer
+func (t *tmpl) Stream(ctx *Context, w io.Writer, data any) error {
+	err := t.stream(ctx, w, data)
+	if err != nil {
+		return &TemplateError{
+			Name:      t.name,
+			Component: t.component,
+			Err:       err,
+			sources:   t.sources,
+		}
+	}
+	return nil
+}
+
+func (t *tmpl) stream(ctx *Context, w io.Writer, data any) error {
+	c := t.c.Load()
+	if c.m == nil {
+		return c.run(ctx, w, "", data)
+	}
+
+	mw := c.m.Writer("text/html", w)
+	err := c.run(ctx, mw, "", data)
+	if cerr := mw.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package hime
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tdewolff/minify/v2/html"
+)
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("x", streamChunkSize)
+
+	serve := func(app *App, h Handler) *httptest.ResponseRecorder {
+		app.Handler(h)
+		return invokeHandler(app, http.MethodGet, "/", nil)
+	}
+
+	newApp := func() *App {
+		app := New()
+		app.Stream = true
+		app.ETag = true
+		app.srv.ErrorLog = log.New(io.Discard, "", 0)
+		tp := app.Template()
+		tp.Func("fail", func() (string, error) { return "", errors.New("fail") })
+		tp.Parse("page", `<p>{{.}}</p>`)
+		tp.Parse("fail", `<p>{{.}}</p>{{fail}}`)
+		tp.ParseComponent("c", `<b>{{.}}</b>`)
+		return app
+	}
+
+	t.Run("small body is not streamed", func(t *testing.T) {
+		app := newApp()
+		w := serve(app, func(ctx *Context) error {
+			return ctx.View("page", "a")
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<p>a</p>", w.Body.String())
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.False(t, w.Flushed)
+	})
+
+	t.Run("large body is streamed", func(t *testing.T) {
+		app := newApp()
+		w := serve(app, func(ctx *Context) error {
+			return ctx.Status(http.StatusCreated).View("page", large)
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "<p>"+large+"</p>", w.Body.String())
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("ETag"))
+		assert.True(t, w.Flushed)
+	})
+
+	t.Run("component", func(t *testing.T) {
+		app := newApp()
+		w := serve(app, func(ctx *Context) error {
+			return ctx.Component("c", large)
+		})
+		assert.Equal(t, "<b>"+large+"</b>", w.Body.String())
+		assert.True(t, w.Flushed)
+	})
+
+	t.Run("context overrides app", func(t *testing.T) {
+		app := newApp()
+		w := serve(app, func(ctx *Context) error {
+			return ctx.Stream(false).View("page", large)
+		})
+		assert.Equal(t, "<p>"+large+"</p>", w.Body.String())
+		assert.NotEmpty(t, w.Header().Get("ETag"))
+		assert.False(t, w.Flushed)
+	})
+
+	t.Run("error before first chunk", func(t *testing.T) {
+		app := newApp()
+		var herr error
+		w := serve(app, func(ctx *Context) error {
+			herr = ctx.View("fail", "a")
+			return herr
+		})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "<p>")
+
+		var streamErr *StreamError
+		assert.False(t, errors.As(herr, &streamErr))
+		var templateErr *TemplateError
+		assert.True(t, errors.As(herr, &templateErr))
+	})
+
+	t.Run("error after first chunk", func(t *testing.T) {
+		app := newApp()
+		var herr error
+		w := serve(app, func(ctx *Context) error {
+			herr = ctx.View("fail", large)
+			return herr
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<p>"+large+"</p>", w.Body.String())
+
+		var streamErr *StreamError
+		assert.True(t, errors.As(herr, &streamErr))
+		var templateErr *TemplateError
+		assert.True(t, errors.As(herr, &templateErr))
+	})
+
+	t.Run("error after first chunk in dev", func(t *testing.T) {
+		app := newApp()
+		app.Dev = true
+		w := serve(app, func(ctx *Context) error {
+			return ctx.View("fail", large)
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<p>"+large+"</p>", w.Body.String())
+	})
+
+	t.Run("error after first chunk with error handler", func(t *testing.T) {
+		app := newApp()
+		app.ErrorHandler = func(ctx *Context, err error) {
+			ctx.Status(http.StatusInternalServerError).String("oops")
+		}
+		w := serve(app, func(ctx *Context) error {
+			return ctx.View("fail", large)
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "<p>"+large+"</p>", w.Body.String())
+	})
+
+	t.Run("runtime minify", func(t *testing.T) {
+		app := New()
+		app.Stream = true
+		tp := app.Template()
+		tp.MinifyWith(TemplateMinifyConfig{
+			HTML: customMinifier{&html.Minifier{}},
+		})
+		tp.Parse("page", `  <p>  {{.}}  </p>  `)
+
+		w := serve(app, func(ctx *Context) error {
+			return ctx.View("page", large)
+		})
+		assert.Equal(t, "<p>"+large, w.Body.String())
+	})
+}
@@ -3,12 +3,19 @@ package hime
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
+	"time"
 
 	"github.com/tdewolff/minify/v2"
 	"github.com/tdewolff/minify/v2/css"
@@ -25,21 +32,105 @@ type TemplateConfig struct {
 	Preload []string            `yaml:"preload" json:"preload"`
 	List    map[string][]string `yaml:"list" json:"list"`
 	Delims  []string            `yaml:"delims" json:"delims"`
+	Errors  ErrorPages          `yaml:"errors" json:"errors"`
+
+	Components TemplateComponents `yaml:"components" json:"components"`
+
+	// Pages is the directory of views, see Template.ParsePages
+	Pages  string   `yaml:"pages" json:"pages"`
+	Layout []string `yaml:"layout" json:"layout"`
+
+	// Layouts are layouts to select at render time, see Template.Layout
+	Layouts map[string][]string `yaml:"layouts" json:"layouts"`
+	Content string              `yaml:"content" json:"content"`
+
+	// Text are text templates, see Template.ParseTextFiles
+	Text map[string][]string `yaml:"text" json:"text"`
+
+	// Markdown is config for markdown views and components, see Template.Markdown
+	Markdown MarkdownConfig `yaml:"markdown" json:"markdown"`
+}
+
+// TemplateComponents is components config
+//
+// Example:
+//
+//	components:
+//	  card: comp/card.tmpl
+//
+// or with globs and directories, component's name is the file name without extension
+//
+//	components:
+//	  - comp/*.tmpl
+//	  - widgets
+//	  - card: comp/card.tmpl
+type TemplateComponents struct {
+	Files map[string]string // component's name to file
+	Globs []string          // glob patterns or directories
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (xs *TemplateComponents) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return node.Decode(&xs.Files)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				xs.Globs = append(xs.Globs, item.Value)
+				continue
+			}
+
+			var files map[string]string
+			if err := item.Decode(&files); err != nil {
+				return err
+			}
+			if xs.Files == nil {
+				xs.Files = make(map[string]string)
+			}
+			for name, filename := range files {
+				xs.Files[name] = filename
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("hime: components must be a map or a list")
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (xs *TemplateComponents) UnmarshalJSON(b []byte) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+	return xs.UnmarshalYAML(node.Content[0])
 }
 
 // Template creates new template loader
 func (app *App) Template() *Template {
 	return &Template{
 		parent:     template.Must(app.parent.Clone()),
+		base:       template.Must(app.parent.Clone()),
 		list:       app.template,
 		components: app.component,
+		text:       texttemplate.Must(app.textParent.Clone()),
+		textList:   app.text,
+		app:        app,
 	}
 }
 
-// TemplateFuncs registers app's level template funcs
+// TemplateFuncs registers app's level template funcs,
+// which replace context funcs with the same name, see ContextFuncs
 func (app *App) TemplateFuncs(funcs ...template.FuncMap) {
 	for _, f := range funcs {
+		for name := range f {
+			delete(app.contextFuncs, name)
+		}
 		app.parent.Funcs(f)
+		app.textParent.Funcs(f)
 	}
 }
 
@@ -49,37 +140,117 @@ func (app *App) TemplateFunc(name string, f any) {
 }
 
 type tmpl struct {
-	*template.Template
-	m *minify.M
+	c atomic.Pointer[compiledTmpl]
+
+	name      string
+	component bool
+	sources   templateSources
+
+	// rebuild re-parses the template from its sources,
+	// nil when the template can not be reloaded
+	rebuild func() *compiledTmpl
+
+	// compose compiles the template returned from f with a new parent,
+	// to combine the view with a layout
+	compose func(f func(newParent func() *template.Template) *template.Template) *compiledTmpl
+	content string // template executed without layout
+
+	mu         sync.Mutex
+	modTime    time.Time // sources' modification time of the current template
+	errModTime time.Time // sources' modification time which failed to parse
+}
+
+// compiledTmpl is the executable template,
+// m is the minifier for the output, nil when the template was minified when parse
+type compiledTmpl struct {
+	t        *template.Template
+	m        *minify.M
+	minified bool
+	pool     *templatePool  // nil when the template does not use context funcs
+	content  *layoutContent // view's content captured when build, nil for components
+	layouts  sync.Map       // layout's name => *layoutTmpl
+}
+
+func newTmpl(t *template.Template, m *minify.M) *tmpl {
+	x := &tmpl{}
+	x.c.Store(&compiledTmpl{t: t, m: m})
+	return x
+}
+
+func (t *tmpl) load() *template.Template {
+	return t.c.Load().t
 }
 
 func (t *tmpl) Execute(w io.Writer, data any) error {
-	// t.m.Writer is too slow for short data (html)
+	return t.ExecuteContext(nil, w, data)
+}
 
-	if t.m == nil {
-		return t.Template.Execute(w, data)
+// ExecuteContext executes the template with context funcs bound to ctx
+func (t *tmpl) ExecuteContext(ctx *Context, w io.Writer, data any) error {
+	err := t.execute(ctx, w, data)
+	if err != nil {
+		return &TemplateError{
+			Name:      t.name,
+			Component: t.component,
+			Err:       err,
+			sources:   t.sources,
+		}
+	}
+	return nil
+}
+
+func (t *tmpl) execute(ctx *Context, w io.Writer, data any) error {
+	// m.Writer is too slow for short data (html)
+
+	c := t.c.Load()
+	if c.m == nil && !c.minified {
+		return c.run(ctx, w, "", data)
 	}
 
 	buf := getBytes()
 	defer putBytes(buf)
 
-	err := t.Template.Execute(buf, data)
+	// keep w's cancellation while render into the buffer
+	var out io.Writer = buf
+	if cw, ok := w.(*contextWriter); ok {
+		out = &contextWriter{ctx: cw.ctx, w: buf}
+	}
+
+	err := c.run(ctx, out, "", data)
 	if err != nil {
 		return err
 	}
 
-	return t.m.Minify("text/html", w, buf)
+	if c.minified {
+		_, err = buf.WriteTo(w)
+		return err
+	}
+	return c.m.Minify("text/html", w, buf)
 }
 
+// templateStep modifies the parent template,
+// steps are recorded so the parent can be rebuilt when reload
+type templateStep func(t *template.Template) (*template.Template, error)
+
 // Template is template loader
 type Template struct {
-	parent     *template.Template
-	list       map[string]*tmpl
-	root       string
-	fs         fs.FS
-	dir        string
-	components map[string]*tmpl
-	minifier   *minify.M
+	parent      *template.Template
+	base        *template.Template // parent before steps
+	steps       []templateStep
+	list        map[string]*tmpl
+	root        string
+	fs          fs.FS
+	dir         string
+	components  map[string]*tmpl
+	minifier    *minify.M
+	preMinifier *minify.M
+	content     string
+	app         *App
+	sources     templateSources // preloaded sources
+	text        *texttemplate.Template
+	textList    map[string]*texttemplate.Template
+	markdown    MarkdownConfig
+	funcs       map[string]bool // names of funcs added by Funcs
 }
 
 // Config loads template config
@@ -93,9 +264,27 @@ func (tp *Template) Config(cfg TemplateConfig) {
 		tp.Minify()
 	}
 	tp.Preload(cfg.Preload...)
+	tp.Content(cfg.Content)
+	tp.Markdown(cfg.Markdown)
+	for name, filenames := range cfg.Layouts {
+		tp.Layout(name, filenames...)
+	}
+	for name, filename := range cfg.Components.Files {
+		tp.ParseComponentFile(name, filename)
+	}
+	for _, pattern := range cfg.Components.Globs {
+		tp.ParseComponentGlob(pattern)
+	}
 	for name, filenames := range cfg.List {
 		tp.ParseFiles(name, filenames...)
 	}
+	for name, filenames := range cfg.Text {
+		tp.ParseTextFiles(name, filenames...)
+	}
+	if cfg.Pages != "" {
+		tp.ParsePages(cfg.Pages, cfg.Layout...)
+	}
+	tp.ErrorPages(cfg.Errors)
 }
 
 // ParseConfig parses template config data
@@ -124,31 +313,53 @@ type TemplateMinifyConfig struct {
 }
 
 // MinifyWith enables minify with custom options, must call before parse
+//
+// Template's text is minified once when parse if HTML is *html.Minifier,
+// otherwise the output is minified when render
 func (tp *Template) MinifyWith(cfg TemplateMinifyConfig) {
-	tp.minifier = minify.New()
-	if cfg.HTML != nil {
-		tp.minifier.Add("text/html", cfg.HTML)
-	}
-	if cfg.CSS != nil {
-		tp.minifier.Add("text/css", cfg.CSS)
-	}
-	if cfg.JS != nil {
-		tp.minifier.Add("application/javascript", cfg.JS)
-	}
+	tp.preMinifier = newPreMinifier(cfg)
+	tp.minifier = newMinifier(cfg)
 }
 
 // Minify enables minify when render html, css, js, must call before parse
 func (tp *Template) Minify() {
-	tp.MinifyWith(TemplateMinifyConfig{
+	tp.MinifyWith(defaultMinifyConfig())
+}
+
+func defaultMinifyConfig() TemplateMinifyConfig {
+	return TemplateMinifyConfig{
 		HTML: &html.Minifier{},
 		CSS:  &css.Minifier{},
 		JS:   &js.Minifier{},
-	})
+	}
+}
+
+func newMinifier(cfg TemplateMinifyConfig) *minify.M {
+	m := minify.New()
+	if cfg.HTML != nil {
+		m.Add("text/html", cfg.HTML)
+	}
+	if cfg.CSS != nil {
+		m.Add("text/css", cfg.CSS)
+	}
+	if cfg.JS != nil {
+		m.Add("application/javascript", cfg.JS)
+	}
+	return m
 }
 
 // Delims sets left and right delims
 func (tp *Template) Delims(left, right string) {
-	tp.parent.Delims(left, right)
+	tp.step(func(t *template.Template) (*template.Template, error) {
+		return t.Delims(left, right), nil
+	})
+	tp.text.Delims(left, right)
+}
+
+// step applies fn to the parent and records it
+func (tp *Template) step(fn templateStep) {
+	template.Must(fn(tp.parent))
+	tp.steps = append(tp.steps, fn)
 }
 
 // Root calls t.Lookup(name) after load template,
@@ -171,10 +382,20 @@ func (tp *Template) FS(fs fs.FS) {
 	tp.fs = fs
 }
 
-// Funcs adds template funcs while load template
+// Funcs adds template funcs while load template,
+// which replace context funcs with the same name in the loaded templates
 func (tp *Template) Funcs(funcs ...template.FuncMap) {
 	for _, f := range funcs {
-		tp.parent.Funcs(f)
+		if tp.funcs == nil {
+			tp.funcs = make(map[string]bool)
+		}
+		for name := range f {
+			tp.funcs[name] = true
+		}
+		tp.step(func(t *template.Template) (*template.Template, error) {
+			return t.Funcs(f), nil
+		})
+		tp.text.Funcs(f)
 	}
 }
 
@@ -189,77 +410,171 @@ func (tp *Template) Preload(filename ...string) {
 		return
 	}
 
-	if tp.fs == nil {
-		template.Must(tp.parent.ParseFiles(joinTemplateDir(tp.dir, filename...)...))
-	} else {
-		template.Must(tp.parent.ParseFS(tp.fs, joinTemplateDir(tp.dir, filename...)...))
+	filenames := joinTemplateDir(tp.dir, filename...)
+	fsys := tp.fs
+	tp.step(func(t *template.Template) (*template.Template, error) {
+		if fsys == nil {
+			return t.ParseFiles(filenames...)
+		}
+		return t.ParseFS(fsys, filenames...)
+	})
+	tp.text = tp.parseTextFiles(tp.text, filenames)
+	if tp.sources == nil {
+		tp.sources = make(templateSources)
 	}
+	tp.sources.addFiles(tp.fs, filenames...)
 }
 
-func (tp *Template) newTemplate(name string, parser func(t *template.Template) *template.Template) {
+// viewSources returns preloaded sources merged with view's sources
+func (tp *Template) viewSources(sources templateSources) templateSources {
+	return mergeTemplateSources(tp.sources, sources)
+}
+
+func mergeTemplateSources(xs ...templateSources) templateSources {
+	rs := make(templateSources)
+	for _, x := range xs {
+		for k, v := range x {
+			rs[k] = v
+		}
+	}
+	return rs
+}
+
+// newTemplate loads a view, entry is the template executed without layout
+// when content is not set
+func (tp *Template) newTemplate(name string, entry string, sources templateSources, parser func(t *template.Template) *template.Template) {
 	if _, ok := tp.list[name]; ok {
 		panic(newErrTemplateDuplicate(name))
 	}
 
-	t := template.Must(tp.parent.Clone()).
-		Funcs(template.FuncMap{
-			"templateName": func() string { return name },
-		})
-
-	t = parser(t)
-
-	if tp.root != "" {
-		t = t.Lookup(tp.root)
-	}
-	if t == nil {
-		panicf("no root layout")
+	root := tp.root
+	content := entry
+	if tp.content != "" {
+		content = tp.content
 	}
+	t := tp.newTmpl(name, false, content, sources, func(parent *template.Template) *template.Template {
+		t := template.Must(parent.Clone()).
+			Funcs(template.FuncMap{
+				"templateName": func() string { return name },
+			})
 
-	tp.list[name] = &tmpl{
-		Template: t,
-		m:        tp.minifier,
-	}
+		t = parser(t)
+
+		if root != "" {
+			t = t.Lookup(root)
+		}
+		if t == nil {
+			panicf("no root layout")
+		}
+		return t
+	})
+	t.content = content
+	tp.list[name] = t
 }
 
-func (tp *Template) newComponent(name string, parser func(t *template.Template) *template.Template) {
+func (tp *Template) newComponent(name string, sources templateSources, parser func(t *template.Template) *template.Template) {
 	if _, ok := tp.components[name]; ok {
 		panic(newErrComponentDuplicate(name))
 	}
 
-	t := template.Must(tp.parent.Clone()).
-		Funcs(template.FuncMap{
-			"componentName": func() string { return name },
-		})
+	tp.components[name] = tp.newTmpl(name, true, "", sources, func(parent *template.Template) *template.Template {
+		t := template.Must(parent.Clone()).
+			Funcs(template.FuncMap{
+				"componentName": func() string { return name },
+			})
 
-	t = parser(t)
+		t = parser(t)
 
-	if t == nil {
-		panicf("nil component")
-	}
+		if t == nil {
+			panicf("nil component")
+		}
+		return t
+	})
+}
 
-	tp.components[name] = &tmpl{
-		Template: t,
-		m:        tp.minifier,
+// newTmpl builds the template from the parent,
+// and remembers how to rebuild it from the recorded steps for reload.
+// Content is the view's content template, empty for components
+func (tp *Template) newTmpl(name string, component bool, content string, sources templateSources, build func(parent *template.Template) *template.Template) *tmpl {
+	m, pm := tp.minifier, tp.preMinifier
+	app := tp.app
+	shadowed := maps.Clone(tp.funcs)
+	sources = tp.viewSources(sources)
+	compile := func(x *template.Template) *compiledTmpl {
+		if pm != nil && preMinify(pm, x) {
+			return &compiledTmpl{t: x, minified: true, pool: newTemplatePool(app, x, shadowed)}
+		}
+		return &compiledTmpl{t: x, m: m, pool: newTemplatePool(app, x, shadowed)}
+	}
+	compileView := func(x *template.Template) *compiledTmpl {
+		var lc *layoutContent
+		if content != "" {
+			// capture before compile, preMinify modifies the trees
+			lc = captureLayoutContent(x, content, sources)
+		}
+		c := compile(x)
+		c.content = lc
+		return c
+	}
+
+	t := &tmpl{}
+	t.c.Store(compileView(build(tp.parent)))
+	t.name = name
+	t.component = component
+	t.sources = sources
+	t.modTime = t.sources.modTime()
+
+	base := tp.base
+	steps := tp.steps[:len(tp.steps):len(tp.steps)]
+	newParent := func() *template.Template {
+		parent := template.Must(base.Clone())
+		for _, step := range steps {
+			parent = template.Must(step(parent))
+		}
+		return parent
+	}
+	t.rebuild = func() *compiledTmpl {
+		return compileView(build(newParent()))
+	}
+	t.compose = func(f func(newParent func() *template.Template) *template.Template) *compiledTmpl {
+		return compile(f(newParent))
 	}
+	return t
+}
+
+// ErrorPages registers error page views into the app,
+// see App.ErrorPages
+func (tp *Template) ErrorPages(pages ErrorPages) {
+	tp.app.ErrorPages(pages)
 }
 
 // Parse parses template from text
 func (tp *Template) Parse(name string, text string) {
-	tp.newTemplate(name, func(t *template.Template) *template.Template {
+	sources := templateSources{name: {text: text}}
+	tp.newTemplate(name, name, sources, func(t *template.Template) *template.Template {
 		return template.Must(t.New(name).Parse(text))
 	})
 }
 
 // ParseFiles loads template from file
 func (tp *Template) ParseFiles(name string, filenames ...string) {
-	tp.newTemplate(name, func(t *template.Template) *template.Template {
-		if tp.fs == nil {
-			t = template.Must(t.ParseFiles(joinTemplateDir(tp.dir, filenames...)...))
-		} else {
-			t = template.Must(t.ParseFS(tp.fs, joinTemplateDir(tp.dir, filenames...)...))
-		}
-		if tp.root == "" {
-			t = t.Lookup(filenames[0])
+	tp.parseFiles(name, joinTemplateDir(tp.dir, filenames...), filenames[0])
+}
+
+// parseFiles loads template from files,
+// view is the template to execute when there is no root
+func (tp *Template) parseFiles(name string, files []string, view string) {
+	fsys := tp.fs
+	root := tp.root
+	md, content := tp.markdown, tp.content
+	sources := make(templateSources)
+	sources.addFiles(fsys, files...)
+	sources.markdown(md)
+
+	tp.newTemplate(name, view, sources, func(t *template.Template) *template.Template {
+		t = parseTemplateFiles(t, fsys, files, md, content)
+		if root == "" {
+			t = t.Lookup(view)
 		}
 		return t
 	})
@@ -271,15 +586,19 @@ func (tp *Template) ParseGlob(name string, pattern string) {
 		panicf("parse glob can not use without root")
 	}
 
-	tp.newTemplate(name, func(t *template.Template) *template.Template {
-		d := tp.dir
-		if !strings.HasSuffix(d, "/") {
-			d += "/"
-		}
-		if tp.fs == nil {
+	d := tp.dir
+	if !strings.HasSuffix(d, "/") {
+		d += "/"
+	}
+	fsys := tp.fs
+	sources := make(templateSources)
+	sources.addGlob(fsys, d+pattern)
+
+	tp.newTemplate(name, "", sources, func(t *template.Template) *template.Template {
+		if fsys == nil {
 			return template.Must(t.ParseGlob(d + pattern))
 		} else {
-			return template.Must(t.ParseFS(tp.fs, d+pattern))
+			return template.Must(t.ParseFS(fsys, d+pattern))
 		}
 	})
 }
@@ -296,36 +615,80 @@ func (tp *Template) Component(ts ...*template.Template) {
 			panicf("component '%s' already exists", name)
 		}
 
-		tp.components[name] = &tmpl{
-			Template: t,
-			m:        tp.minifier,
-		}
+		x := newTmpl(t, tp.minifier)
+		x.name = name
+		x.component = true
+		tp.components[name] = x
 	}
 }
 
 // ParseComponent parses component from text
 func (tp *Template) ParseComponent(name string, text string) {
-	tp.newComponent(name, func(t *template.Template) *template.Template {
+	sources := templateSources{name: {text: text}}
+	tp.newComponent(name, sources, func(t *template.Template) *template.Template {
 		return template.Must(t.New(name).Parse(text))
 	})
 }
 
 // ParseComponentFile loads component from file
 func (tp *Template) ParseComponentFile(name string, filename string) {
-	tp.newComponent(name, func(t *template.Template) *template.Template {
-		if tp.fs == nil {
-			t = template.Must(t.ParseFiles(joinTemplateDir(tp.dir, filename)...))
-		} else {
-			t = template.Must(t.ParseFS(tp.fs, joinTemplateDir(tp.dir, filename)...))
+	tp.parseComponentFile(name, joinTemplateDir(tp.dir, filename)[0])
+}
+
+// ParseComponentGlob loads components from files matching pattern,
+// or all files in the directory when pattern is a directory.
+//
+// Component's name is the file name without extension,
+// e.g. "comp/card.tmpl" is loaded as "card"
+func (tp *Template) ParseComponentGlob(pattern string) {
+	pattern = joinTemplateDir(tp.dir, pattern)[0]
+
+	if isTemplateDir(tp.fs, pattern) {
+		pattern = path.Join(pattern, "*")
+	}
+
+	var filenames []string
+	var err error
+	if tp.fs == nil {
+		filenames, err = filepath.Glob(pattern)
+	} else {
+		filenames, err = fs.Glob(tp.fs, pattern)
+	}
+	if err != nil {
+		panicf("parse component glob; %v", err)
+	}
+
+	for _, filename := range filenames {
+		if isTemplateDir(tp.fs, filename) {
+			continue
 		}
+		base := path.Base(filepath.ToSlash(filename))
+		tp.parseComponentFile(strings.TrimSuffix(base, path.Ext(base)), filename)
+	}
+}
+
+func (tp *Template) parseComponentFile(name string, filename string) {
+	files := []string{filename}
+	fsys := tp.fs
+	md := tp.markdown
+	sources := make(templateSources)
+	sources.addFiles(fsys, files...)
+	sources.markdown(md)
+
+	tp.newComponent(name, sources, func(t *template.Template) *template.Template {
+		t = parseTemplateFiles(t, fsys, files, md)
 		t = t.Lookup(path.Base(filename))
 		return t
 	})
 }
 
 func (app *App) renderComponent(name string, args ...any) template.HTML {
-	t := app.component[name]
-	if t == nil {
+	return app.renderComponentContext(nil, name, args...)
+}
+
+func (app *App) renderComponentContext(ctx *Context, name string, args ...any) template.HTML {
+	t, ok := app.lookupComponent(name)
+	if !ok {
 		panicf("component '%s' not found", name)
 	}
 
@@ -341,14 +704,27 @@ func (app *App) renderComponent(name string, args ...any) template.HTML {
 	buf := getBytes()
 	defer putBytes(buf)
 
-	err := t.Execute(buf, d)
+	err := t.ExecuteContext(ctx, buf, d)
 	if err != nil {
-		panicf("component '%s' execute error: %v", name, err)
+		// panic with an error, so text/template wraps it
+		// and the component's TemplateError stays in the chain
+		panic(fmt.Errorf("hime: component '%s' execute error: %w", name, err))
 	}
 
 	return template.HTML(buf.String())
 }
 
+func isTemplateDir(fsys fs.FS, name string) bool {
+	var fi fs.FileInfo
+	var err error
+	if fsys == nil {
+		fi, err = os.Stat(name)
+	} else {
+		fi, err = fs.Stat(fsys, name)
+	}
+	return err == nil && fi.IsDir()
+}
+
 func joinTemplateDir(dir string, filenames ...string) []string {
 	xs := make([]string, len(filenames))
 	for i, filename := range filenames {
@@ -357,6 +733,49 @@ func joinTemplateDir(dir string, filenames ...string) []string {
 	return xs
 }
 
+// templateSource is where a parsed template's text comes from
+type templateSource struct {
+	fs       fs.FS
+	path     string
+	text     string
+	markdown *MarkdownConfig // converts markdown when reparse
+}
+
+func (s templateSource) read() (string, error) {
+	if s.path == "" {
+		return s.text, nil
+	}
+
+	var b []byte
+	var err error
+	if s.fs == nil {
+		b, err = os.ReadFile(s.path)
+	} else {
+		b, err = fs.ReadFile(s.fs, s.path)
+	}
+	return string(b), err
+}
+
+// templateSources is the map for parse name => source,
+// parse name is the template name which text/template reports in errors
+type templateSources map[string]templateSource
+
+func (xs templateSources) addFiles(fsys fs.FS, filenames ...string) {
+	for _, filename := range filenames {
+		xs[path.Base(filename)] = templateSource{fs: fsys, path: filename}
+	}
+}
+
+func (xs templateSources) addGlob(fsys fs.FS, pattern string) {
+	var filenames []string
+	if fsys == nil {
+		filenames, _ = filepath.Glob(pattern)
+	} else {
+		filenames, _ = fs.Glob(fsys, pattern)
+	}
+	xs.addFiles(fsys, filenames...)
+}
+
 func cloneTmpl(xs map[string]*tmpl) map[string]*tmpl {
 	if xs == nil {
 		return nil
@@ -0,0 +1,106 @@
+package hime
+
+import (
+	"context"
+	"io"
+	"path"
+	texttemplate "text/template"
+)
+
+// ParseText parses a text template (text/template) from text,
+// text templates share funcs, delims and preloaded templates with views,
+// e.g. for plain-text emails, see App.RenderText
+func (tp *Template) ParseText(name string, text string) {
+	tp.newText(name, func(t *texttemplate.Template) *texttemplate.Template {
+		return texttemplate.Must(t.New(name).Parse(text))
+	})
+}
+
+// ParseTextFiles loads a text template from files, see ParseText.
+//
+// The text template executes the root template (see Root),
+// or the first file when there is no root.
+func (tp *Template) ParseTextFiles(name string, filenames ...string) {
+	files := joinTemplateDir(tp.dir, filenames...)
+	tp.newText(name, func(t *texttemplate.Template) *texttemplate.Template {
+		t = tp.parseTextFiles(t, files)
+		if tp.root == "" {
+			t = t.Lookup(path.Base(files[0]))
+		}
+		return t
+	})
+}
+
+func (tp *Template) parseTextFiles(t *texttemplate.Template, files []string) *texttemplate.Template {
+	if tp.fs == nil {
+		return texttemplate.Must(t.ParseFiles(files...))
+	}
+	return texttemplate.Must(t.ParseFS(tp.fs, files...))
+}
+
+func (tp *Template) newText(name string, parser func(t *texttemplate.Template) *texttemplate.Template) {
+	if _, ok := tp.textList[name]; ok {
+		panicf("text template '%s' already exists", name)
+	}
+
+	t := texttemplate.Must(tp.text.Clone()).
+		Funcs(texttemplate.FuncMap{
+			"templateName": func() string { return name },
+		})
+
+	t = parser(t)
+
+	if t != nil && tp.root != "" {
+		t = t.Lookup(tp.root)
+	}
+	if t == nil {
+		panicf("no root layout")
+	}
+	tp.textList[name] = t
+}
+
+// RenderText renders the text template into w
+//
+// It panics if the text template is not found, matching RenderView.
+func (app *App) RenderText(w io.Writer, name string, data any) error {
+	return app.RenderTextContext(context.Background(), w, name, data)
+}
+
+// RenderTextContext renders the text template into w, see RenderText.
+//
+// Rendering stops with ctx's error when ctx is done.
+func (app *App) RenderTextContext(ctx context.Context, w io.Writer, name string, data any) error {
+	t, ok := app.text[name]
+	if !ok {
+		panic(newErrTemplateNotFound(name))
+	}
+	return renderText(ctx, w, t, data)
+}
+
+func renderText(ctx context.Context, w io.Writer, t *texttemplate.Template, data any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	buf := getBytes()
+	defer putBytes(buf)
+
+	err := t.Execute(&contextWriter{ctx: ctx, w: buf}, data)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func cloneTextTmpl(xs map[string]*texttemplate.Template) map[string]*texttemplate.Template {
+	rs := make(map[string]*texttemplate.Template, len(xs))
+	for k, v := range xs {
+		rs[k] = v
+	}
+	return rs
+}
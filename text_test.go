@@ -0,0 +1,75 @@
+package hime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ParseText", func(t *testing.T) {
+		app := New()
+		app.Routes(Routes{"home": "/home"})
+		app.Globals(Globals{"site": "hime"})
+		app.TemplateFunc("upper", strings.ToUpper)
+		tp := app.Template()
+		tp.Func("lower", strings.ToLower)
+		tp.ParseText("t", `<{{.}}> {{route "home"}} {{global "site" | upper}} {{"X" | lower}} {{templateName}}`)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderText(&b, "t", "a&b"))
+		assert.Equal(t, "<a&b> /home HIME x t", b.String())
+	})
+
+	t.Run("ParseTextFiles with preload", func(t *testing.T) {
+		app := New()
+		app.Routes(Routes{"home": "/home"})
+		app.Globals(Globals{"site": "hime"})
+		tp := app.Template()
+		tp.Dir("testdata/mail")
+		tp.Preload("_layout.tmpl")
+		tp.ParseTextFiles("welcome", "welcome.txt")
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderText(&b, "welcome", map[string]any{"Name": "A"}))
+		assert.Equal(t, "Hi A,\nVisit /home.\nSent by hime\n", b.String())
+	})
+
+	t.Run("delims", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.Delims("[[", "]]")
+		tp.ParseText("t", `[[.]]{{.}}`)
+
+		var b strings.Builder
+		assert.NoError(t, app.RenderText(&b, "t", 1))
+		assert.Equal(t, "1{{.}}", b.String())
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		tp := New().Template()
+		tp.ParseText("t", ``)
+		assert.Panics(t, func() { tp.ParseText("t", ``) })
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		assert.Panics(t, func() { New().RenderText(&strings.Builder{}, "t", nil) })
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		app := New()
+		app.Template().ParseText("t", `a`)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var b strings.Builder
+		err := app.RenderTextContext(ctx, &b, "t", nil)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Empty(t, b.String())
+	})
+}
@@ -0,0 +1,262 @@
+package hime
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// ValidateError is the error from App.Validate,
+// it contains all invalid references found in templates
+type ValidateError struct {
+	Errors []error
+}
+
+func (err *ValidateError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "hime: template validation failed with %d errors", len(err.Errors))
+	for _, e := range err.Errors {
+		b.WriteString("\n\t")
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors
+func (err *ValidateError) Unwrap() []error {
+	return err.Errors
+}
+
+// Validate checks route, component and global calls with literal arguments
+// in all views, components, layouts and text templates, so typos are found at startup instead of render.
+//
+// It reports unknown routes, missing components and wrong number of arguments
+// as *ValidateError.
+func (app *App) Validate() error {
+	v := validator{
+		app:  app,
+		seen: make(map[string]bool),
+	}
+
+	names := make([]string, 0, len(app.template))
+	for name := range app.template {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.tmpl(app.template[name])
+	}
+
+	names = names[:0]
+	for name := range app.component {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.tmpl(app.component[name])
+	}
+
+	names = names[:0]
+	for name := range app.layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.layout(app.layouts[name])
+	}
+
+	names = names[:0]
+	for name := range app.text {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.text(app.text[name])
+	}
+
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return &ValidateError{Errors: v.errors}
+}
+
+type validator struct {
+	app    *App
+	tree   *parse.Tree
+	seen   map[string]bool
+	errors []error
+}
+
+func (v *validator) tmpl(t *tmpl) {
+	ts := t.load().Templates()
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name() < ts[j].Name() })
+	for _, x := range ts {
+		v.template(x)
+	}
+}
+
+func (v *validator) layout(l *layout) {
+	trees := append([]*parse.Tree(nil), l.trees...)
+	sort.Slice(trees, func(i, j int) bool { return trees[i].Name < trees[j].Name })
+	for _, x := range trees {
+		v.parseTree(x)
+	}
+}
+
+func (v *validator) text(t *texttemplate.Template) {
+	ts := t.Templates()
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name() < ts[j].Name() })
+	for _, x := range ts {
+		v.parseTree(x.Tree)
+	}
+}
+
+func (v *validator) template(t *template.Template) {
+	v.parseTree(t.Tree)
+}
+
+func (v *validator) parseTree(tree *parse.Tree) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+	v.tree = tree
+	v.walk(tree.Root)
+}
+
+func (v *validator) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, x := range n.Nodes {
+			v.walk(x)
+		}
+	case *parse.ActionNode:
+		v.walk(n.Pipe)
+	case *parse.IfNode:
+		v.branch(&n.BranchNode)
+	case *parse.RangeNode:
+		v.branch(&n.BranchNode)
+	case *parse.WithNode:
+		v.branch(&n.BranchNode)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			v.walk(n.Pipe)
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for i, cmd := range n.Cmds {
+			v.command(cmd, i > 0)
+		}
+	}
+}
+
+func (v *validator) branch(n *parse.BranchNode) {
+	v.walk(n.Pipe)
+	v.walk(n.List)
+	if n.ElseList != nil {
+		v.walk(n.ElseList)
+	}
+}
+
+// command checks the command, piped reports whether the command
+// receives the result of the previous command as its last argument
+func (v *validator) command(cmd *parse.CommandNode, piped bool) {
+	for _, arg := range cmd.Args {
+		if p, ok := arg.(*parse.PipeNode); ok {
+			v.walk(p)
+		}
+	}
+
+	if len(cmd.Args) == 0 {
+		return
+	}
+	fn, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return
+	}
+
+	args := cmd.Args[1:]
+	n := len(args)
+	if piped {
+		n++
+	}
+
+	// name is the first argument when it is a string literal
+	var name string
+	var hasName bool
+	if len(args) > 0 {
+		if s, ok := args[0].(*parse.StringNode); ok {
+			name, hasName = s.Text, true
+		}
+	}
+
+	switch fn.Ident {
+	case "route":
+		if n == 0 {
+			v.errorf(cmd, "route wants at least 1 argument, got 0")
+			return
+		}
+		if hasName {
+			if _, ok := v.app.routes[name]; !ok {
+				v.error(cmd, newErrRouteNotFound(name))
+			}
+		}
+	case "component":
+		if n == 0 || n > 2 {
+			v.errorf(cmd, "component wants 1-2 arguments, got %d", n)
+			return
+		}
+		if hasName {
+			if _, ok := v.app.component[name]; !ok {
+				v.error(cmd, newErrComponentNotFound(name))
+			}
+		}
+	case "global":
+		if n != 1 {
+			v.errorf(cmd, "global wants 1 argument, got %d", n)
+		}
+	case "sanitize":
+		if n == 0 || n > 2 {
+			v.errorf(cmd, "sanitize wants 1-2 arguments, got %d", n)
+			return
+		}
+		if hasName && n == 2 {
+			if _, err := v.app.Sanitize(name, ""); err != nil {
+				v.error(cmd, err)
+			}
+		}
+	case "asset", "integrity":
+		if n != 1 {
+			v.errorf(cmd, "%s wants 1 argument, got %d", fn.Ident, n)
+			return
+		}
+		if hasName && v.app.assets != nil {
+			if _, err := v.app.lookupAsset(name); err != nil {
+				v.error(cmd, err)
+			}
+		}
+	}
+}
+
+func (v *validator) errorf(node parse.Node, format string, a ...any) {
+	v.error(node, fmt.Errorf(format, a...))
+}
+
+func (v *validator) error(node parse.Node, err error) {
+	location, _ := v.tree.ErrorContext(node)
+	err = fmt.Errorf("%s: %w", location, err)
+
+	msg := err.Error()
+	if v.seen[msg] {
+		return
+	}
+	v.seen[msg] = true
+	v.errors = append(v.errors, err)
+}
@@ -0,0 +1,152 @@
+package hime
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		app := New()
+		app.Routes(Routes{"about": "/about"})
+		tp := app.Template()
+		tp.ParseComponent("card", `<div>{{.}}</div>`)
+		tp.Parse("index", `<a href="{{route "about"}}">{{component "card" .}}</a>{{global "x"}}{{route .Name}}{{"about" | route}}`)
+
+		assert.NoError(t, app.Validate())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		app := New()
+		app.Routes(Routes{"about": "/about"})
+		tp := app.Template()
+		tp.ParseComponent("card", `<div>{{route "abuot"}}</div>`)
+		tp.Parse("index", `<a href="{{route "abuot"}}">
+{{if .}}{{component "crad"}}{{end}}
+{{component "card" 1 2}}
+{{global}}
+{{printf "%s" (route)}}
+</a>`)
+
+		err := app.Validate()
+
+		var verr *ValidateError
+		if assert.True(t, errors.As(err, &verr)) {
+			var msgs []string
+			for _, e := range verr.Errors {
+				msgs = append(msgs, e.Error())
+			}
+			assert.Equal(t, []string{
+				"index:1:11: hime: route 'abuot' not found",
+				"index:2:10: hime: component 'crad' not found",
+				"index:3:2: component wants 1-2 arguments, got 3",
+				"index:4:2: global wants 1 argument, got 0",
+				"index:5:15: route wants at least 1 argument, got 0",
+				"card:1:7: hime: route 'abuot' not found",
+			}, msgs)
+		}
+
+		var routeErr *ErrRouteNotFound
+		assert.True(t, errors.As(err, &routeErr))
+		var componentErr *ErrComponentNotFound
+		assert.True(t, errors.As(err, &componentErr))
+		assert.Contains(t, err.Error(), "hime: template validation failed with 6 errors")
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		app := New()
+		app.Routes(Routes{"about": "/about"})
+		tp := app.Template()
+		tp.ParseLayout("main", `<a href="{{route "abuot"}}">{{template "body" .}}</a>`)
+		tp.Parse("index", `{{define "body"}}{{route "about"}}{{end}}`)
+
+		var verr *ValidateError
+		if assert.True(t, errors.As(app.Validate(), &verr)) && assert.Len(t, verr.Errors, 1) {
+			assert.Equal(t, "main:1:11: hime: route 'abuot' not found", verr.Errors[0].Error())
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		app := New()
+		tp := app.Template()
+		tp.ParseText("mail", `{{define "subject"}}{{global}}{{end}}{{route "nope"}}`)
+
+		var verr *ValidateError
+		if assert.True(t, errors.As(app.Validate(), &verr)) {
+			var msgs []string
+			for _, e := range verr.Errors {
+				msgs = append(msgs, e.Error())
+			}
+			assert.Equal(t, []string{
+				"mail:1:39: hime: route 'nope' not found",
+				"mail:1:22: global wants 1 argument, got 0",
+			}, msgs)
+		}
+	})
+
+	t.Run("shared templates are reported once", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplateFile(t, filepath.Join(dir, "nav.tmpl"), `{{define "nav"}}{{route "missing"}}{{end}}`, time.Now())
+
+		app := New()
+		tp := app.Template()
+		tp.Dir(dir)
+		tp.Preload("nav.tmpl")
+		tp.Parse("a", `{{template "nav"}}`)
+		tp.Parse("b", `{{template "nav"}}`)
+
+		var verr *ValidateError
+		if assert.True(t, errors.As(app.Validate(), &verr)) {
+			assert.Len(t, verr.Errors, 1)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		app := New()
+		app.Strict = true
+		app.Template().Parse("index", `{{route "missing"}}`)
+		app.Handler(Handler(func(ctx *Context) error {
+			return ctx.String("ok")
+		}))
+
+		var logs bytes.Buffer
+		app.srv.ErrorLog = log.New(&logs, "", 0)
+
+		for i := 0; i < 2; i++ {
+			w := invokeHandler(app, http.MethodGet, "/", nil)
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+		}
+		assert.Equal(t, 1, strings.Count(logs.String(), "hime: invalid templates;"))
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if assert.NoError(t, err) {
+			defer l.Close()
+			var verr *ValidateError
+			assert.True(t, errors.As(app.Serve(l), &verr))
+		}
+	})
+
+	t.Run("strict valid", func(t *testing.T) {
+		app := New()
+		app.Strict = true
+		app.Routes(Routes{"index": "/"})
+		app.Template().Parse("index", `{{route "index"}}`)
+		app.Handler(Handler(func(ctx *Context) error {
+			return ctx.String("ok")
+		}))
+
+		w := invokeHandler(app, http.MethodGet, "/", nil)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+}
@@ -0,0 +1,71 @@
+package hime
+
+// ViewDataFunc returns the default data for views of the request, see App.ViewData
+type ViewDataFunc func(ctx *Context) map[string]any
+
+// SetViewData sets a value which is merged into the data of views
+// rendered by the request, see App.ViewData
+func (ctx *Context) SetViewData(key string, value any) {
+	if ctx.viewData == nil {
+		ctx.viewData = make(map[string]any)
+	}
+	ctx.viewData[key] = value
+}
+
+// ViewData returns the request's default view data,
+// values from App.ViewData overridden by values from SetViewData.
+//
+// App.ViewData is called once per context, its result is reused by later calls.
+func (ctx *Context) ViewData() map[string]any {
+	rs := make(map[string]any)
+	for k, v := range ctx.appViewData() {
+		rs[k] = v
+	}
+	for k, v := range ctx.viewData {
+		rs[k] = v
+	}
+	return rs
+}
+
+// viewDataValue returns the value of key from ViewData without merging
+func (ctx *Context) viewDataValue(key string) any {
+	if v, ok := ctx.viewData[key]; ok {
+		return v
+	}
+	return ctx.appViewData()[key]
+}
+
+// appViewData returns the result of App.ViewData,
+// the hook is called only once for the context
+func (ctx *Context) appViewData() map[string]any {
+	if ctx.hookData == nil {
+		ctx.hookData = map[string]any{}
+		if f := ctx.app.ViewData; f != nil {
+			if m := f(ctx); m != nil {
+				ctx.hookData = m
+			}
+		}
+	}
+	return ctx.hookData
+}
+
+// withViewData merges the default view data into data
+// when data is nil or map[string]any, data's values take precedence.
+// Other data is returned unchanged.
+func (ctx *Context) withViewData(data any) any {
+	if ctx.app.ViewData == nil && len(ctx.viewData) == 0 {
+		return data
+	}
+
+	switch d := data.(type) {
+	case nil:
+		return ctx.ViewData()
+	case map[string]any:
+		rs := ctx.ViewData()
+		for k, v := range d {
+			rs[k] = v
+		}
+		return rs
+	}
+	return data
+}
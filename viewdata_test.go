@@ -0,0 +1,125 @@
+package hime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewData(t *testing.T) {
+	t.Parallel()
+
+	newApp := func() *App {
+		app := New()
+		app.ViewData = func(ctx *Context) map[string]any {
+			return map[string]any{
+				"User":  "guest",
+				"Title": "site",
+				"Path":  ctx.URL.Path,
+			}
+		}
+		tp := app.Template()
+		tp.Parse("page", `{{.User}}|{{.Title}}|{{.Path}}|{{.Body}}`)
+		tp.ParseComponent("c", `{{.User}}|{{.Title}}`)
+		return app
+	}
+	render := func(app *App, f func(ctx *Context) error) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/about", nil)
+		assert.NoError(t, f(NewAppContext(app, w, r)))
+		return w.Body.String()
+	}
+
+	t.Run("nil data", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "guest|site|/about|", render(app, func(ctx *Context) error {
+			return ctx.View("page", nil)
+		}))
+	})
+
+	t.Run("handler's data takes precedence", func(t *testing.T) {
+		app := newApp()
+		data := map[string]any{"Title": "about", "Body": "b"}
+		assert.Equal(t, "guest|about|/about|b", render(app, func(ctx *Context) error {
+			return ctx.View("page", data)
+		}))
+		assert.Len(t, data, 2, "handler's data must not be modified")
+	})
+
+	t.Run("SetViewData", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "alice|site|/about|", render(app, func(ctx *Context) error {
+			ctx.SetViewData("User", "alice")
+			return ctx.View("page", nil)
+		}))
+	})
+
+	t.Run("without hook", func(t *testing.T) {
+		app := New()
+		app.Template().Parse("page", `{{.User}}`)
+		assert.Equal(t, "bob", render(app, func(ctx *Context) error {
+			ctx.SetViewData("User", "bob")
+			return ctx.View("page", nil)
+		}))
+	})
+
+	t.Run("component and render", func(t *testing.T) {
+		app := newApp()
+		assert.Equal(t, "guest|c", render(app, func(ctx *Context) error {
+			return ctx.Component("c", map[string]any{"Title": "c"})
+		}))
+		assert.Equal(t, "guest", render(app, func(ctx *Context) error {
+			return ctx.Render(`{{.User}}`, nil)
+		}))
+		assert.Equal(t, "guest|site", render(app, func(ctx *Context) error {
+			s, err := ctx.RenderComponentToString("c", nil)
+			ctx.String("%s", s)
+			return err
+		}))
+	})
+
+	t.Run("other data is unchanged", func(t *testing.T) {
+		app := newApp()
+		app.Template().Parse("struct", `{{.Body}}`)
+		assert.Equal(t, "b", render(app, func(ctx *Context) error {
+			return ctx.View("struct", struct{ Body string }{"b"})
+		}))
+	})
+
+	t.Run("hook is called once per context", func(t *testing.T) {
+		app := New()
+		calls := 0
+		app.ViewData = func(ctx *Context) map[string]any {
+			calls++
+			return map[string]any{"User": "guest", "Title": "site"}
+		}
+		tp := app.Template()
+		tp.ParseComponent("c", `{{viewData "User"}}`)
+		tp.Parse("page", `{{viewData "User"}}|{{viewData "Title"}}|{{.User}}|{{component "c"}}`)
+
+		assert.Equal(t, "guest|x|guest|guestguest|x|guest|guest", render(app, func(ctx *Context) error {
+			ctx.SetViewData("Title", "x")
+			if err := ctx.View("page", nil); err != nil {
+				return err
+			}
+			return ctx.View("page", map[string]any{})
+		}))
+		assert.Equal(t, 1, calls)
+
+		render(app, func(ctx *Context) error {
+			return ctx.View("page", nil)
+		})
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("ViewData", func(t *testing.T) {
+		app := newApp()
+		render(app, func(ctx *Context) error {
+			ctx.SetViewData("Title", "x")
+			assert.Equal(t, map[string]any{"User": "guest", "Title": "x", "Path": "/about"}, ctx.ViewData())
+			return nil
+		})
+	})
+}